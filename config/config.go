@@ -19,9 +19,16 @@ type Config struct {
 	GHUserToken            string `envconfig:"GH_USER_TOKEN"`
 	GHAutoGenCommitMsg     string `envconfig:"GH_AUTOGEN_COMMIT_MSG" default:"auto#gen"`
 	GHWebhookBranch        string `envconfig:"GH_WEBHOOK_BRANCH" default:"master"`
+	GHIncrementalPush      bool   `envconfig:"GH_INCREMENTAL_PUSH" default:"false"`
+	GHPruneOrphans         bool   `envconfig:"GH_PRUNE_ORPHANS" default:"false"`
+	GHIncludeDrafts        bool   `envconfig:"GH_INCLUDE_DRAFTS" default:"false"`
 	ServerNickname         string `envconfig:"SERVER_NICKNAME" default:"EOCS_GH"`
 	ElasticsearchURI       string `envconfig:"ELASTICSEARCH_URI"`
 	ElasticsearchBaseIndex string `envconfig:"ELASTICSEARCH_BASE_INDEX" default:"learn"`
+	ElasticsearchBulkSize  int    `envconfig:"ELASTICSEARCH_BULK_SIZE" default:"500"`
+	ElasticsearchRetrySecs int    `envconfig:"ELASTICSEARCH_RETRY_SECS" default:"60"`
+	ContentVersionsKept    int    `envconfig:"CONTENT_VERSIONS_KEPT" default:"10"`
+	AssetsBaseURL          string `envconfig:"ASSETS_BASE_URL"`
 	SMTPFromName           string `envconfig:"SMTP_FROM_NAME" default:"EOCS Course Loader Service"`
 	SMTPFromAddress        string `envconfig:"SMTP_FROM_ADDRESS" default:"noreply@exlskills.com"`
 	SMTPHost               string `envconfig:"SMTP_HOST" default:"smtp.sendgrid.net"`
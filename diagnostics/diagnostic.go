@@ -0,0 +1,83 @@
+package diagnostics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Severity tells whether a problem makes the course fail to load or is only worth a look
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a course
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	// Path is the file or directory that the problem is in, relative to the course folder and with forward slashes
+	Path string `json:"path,omitempty"`
+	// Line is the 1-based line of the problem in the file, or 0 where it is not known
+	Line int `json:"line,omitempty"`
+	// Rule identifies the check that found the problem, e.g., index-yaml or asset-link
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errorf returns an error diagnostic of the rule
+func Errorf(path string, line int, rule, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: SeverityError, Path: path, Line: line, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warnf returns a warning diagnostic of the rule
+func Warnf(path string, line int, rule, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: SeverityWarning, Path: path, Line: line, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// String formats the diagnostic as path:line: severity: message [rule]
+func (d Diagnostic) String() string {
+	location := d.Path
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+	}
+	if location != "" {
+		location += ": "
+	}
+	return fmt.Sprintf("%s%s: %s [%s]", location, d.Severity, d.Message, d.Rule)
+}
+
+// HasErrors returns whether any of the diagnostics is an error
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort orders the diagnostics by path and line, keeping the order of those of the same line
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Path != diags[j].Path {
+			return diags[i].Path < diags[j].Path
+		}
+		return diags[i].Line < diags[j].Line
+	})
+}
+
+// yamlLineRegex finds the line in the errors of the YAML parser, e.g., "yaml: line 3: mapping values are not allowed"
+var yamlLineRegex = regexp.MustCompile(`\bline (\d+)\b`)
+
+// LineFromYAMLError returns the line that a YAML parser error points at, or 0 if it does not
+func LineFromYAMLError(err error) int {
+	m := yamlLineRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
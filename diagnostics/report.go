@@ -0,0 +1,139 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The formats that a report can be written in
+const (
+	FormatText = "text"
+	// FormatJSON is an array of the diagnostics
+	FormatJSON = "json"
+	// FormatGitHub is the workflow commands that make GitHub Actions annotate the files of a pull request
+	FormatGitHub = "github"
+	// FormatCheckstyle is the Checkstyle XML that CI servers and code review tools read
+	FormatCheckstyle = "checkstyle"
+)
+
+var Formats = []string{FormatText, FormatJSON, FormatGitHub, FormatCheckstyle}
+
+// Write writes the diagnostics of the course in rootDir in the format. The text and JSON formats keep the paths
+// relative to the course folder, GitHub Actions takes them relative to the working directory, which is the checkout of
+// the repository, and Checkstyle takes them absolute
+func Write(w io.Writer, format, rootDir string, diags []Diagnostic) error {
+	switch format {
+	case FormatText:
+		return writeText(w, diags)
+	case FormatJSON:
+		if diags == nil {
+			diags = []Diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	case FormatGitHub:
+		return writeGitHub(w, rootDir, diags)
+	case FormatCheckstyle:
+		return writeCheckstyle(w, rootDir, diags)
+	}
+	return errors.New(fmt.Sprintf("unknown diagnostics format %q, it must be one of %s", format, strings.Join(Formats, ", ")))
+}
+
+func writeText(w io.Writer, diags []Diagnostic) error {
+	errs := 0
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errs++
+		}
+		if _, err := fmt.Fprintln(w, d.String()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings\n", errs, len(diags)-errs)
+	return err
+}
+
+func writeGitHub(w io.Writer, rootDir string, diags []Diagnostic) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	for _, d := range diags {
+		var props []string
+		if d.Path != "" {
+			p := filepath.Join(rootDir, filepath.FromSlash(d.Path))
+			if rel, err := filepath.Rel(cwd, p); err == nil {
+				p = rel
+			}
+			props = append(props, "file="+escapeGitHubProperty(filepath.ToSlash(p)))
+			if d.Line > 0 {
+				props = append(props, "line="+strconv.Itoa(d.Line))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(d.Rule))
+		_, err = fmt.Fprintf(w, "::%s %s::%s\n", d.Severity, strings.Join(props, ","), escapeGitHubData(d.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name          `xml:"checkstyle"`
+	Version string            `xml:"version,attr"`
+	Files   []*checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeCheckstyle(w io.Writer, rootDir string, diags []Diagnostic) error {
+	report := &checkstyleReport{Version: "4.3"}
+	files := make(map[string]*checkstyleFile)
+	for _, d := range diags {
+		name := filepath.Join(rootDir, filepath.FromSlash(d.Path))
+		file, ok := files[name]
+		if !ok {
+			file = &checkstyleFile{Name: name}
+			files[name] = file
+			report.Files = append(report.Files, file)
+		}
+		file.Errors = append(file.Errors, checkstyleError{Line: d.Line, Severity: string(d.Severity), Message: d.Message, Source: "eocsutil." + d.Rule})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,159 @@
+package diagnostics
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testDiags = []Diagnostic{
+	Errorf("00_Basics/00_Loops/01_Check/00_For.prob.md", 3, "problem", "invalid front matter: points must not be negative, got -1"),
+	Warnf("00_Basics/index.yaml", 0, "translation", "display_name, is: not\ntranslated 100%%"),
+	Errorf("00_Basics/00_Loops/01_Check/00_For.prob.md", 7, "asset-link", "assets/x.png does not lead to an asset of the course"),
+	Errorf("", 0, "course-settings", "the course has no chapters"),
+}
+
+func TestWrite(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format  string
+		rootDir string
+		diags   []Diagnostic
+		want    string
+		wantErr string
+	}{
+		{
+			format: FormatText,
+			diags:  testDiags,
+			want: `00_Basics/00_Loops/01_Check/00_For.prob.md:3: error: invalid front matter: points must not be negative, got -1 [problem]
+00_Basics/index.yaml: warning: display_name, is: not
+translated 100% [translation]
+00_Basics/00_Loops/01_Check/00_For.prob.md:7: error: assets/x.png does not lead to an asset of the course [asset-link]
+error: the course has no chapters [course-settings]
+3 errors, 1 warnings
+`,
+		},
+		{
+			format: FormatText,
+			want:   "0 errors, 0 warnings\n",
+		},
+		{
+			format: FormatJSON,
+			diags:  testDiags[:2],
+			want: `[
+  {
+    "severity": "error",
+    "path": "00_Basics/00_Loops/01_Check/00_For.prob.md",
+    "line": 3,
+    "rule": "problem",
+    "message": "invalid front matter: points must not be negative, got -1"
+  },
+  {
+    "severity": "warning",
+    "path": "00_Basics/index.yaml",
+    "rule": "translation",
+    "message": "display_name, is: not\ntranslated 100%"
+  }
+]
+`,
+		},
+		{
+			format: FormatJSON,
+			want:   "[]\n",
+		},
+		{
+			format:  FormatGitHub,
+			rootDir: filepath.Join(cwd, "courses", "go"),
+			diags:   testDiags,
+			want: `::error file=courses/go/00_Basics/00_Loops/01_Check/00_For.prob.md,line=3,title=problem::invalid front matter: points must not be negative, got -1
+::warning file=courses/go/00_Basics/index.yaml,title=translation::display_name, is: not%0Atranslated 100%25
+::error file=courses/go/00_Basics/00_Loops/01_Check/00_For.prob.md,line=7,title=asset-link::assets/x.png does not lead to an asset of the course
+::error title=course-settings::the course has no chapters
+`,
+		},
+		{
+			format:  FormatCheckstyle,
+			rootDir: "/courses/go",
+			diags:   testDiags[:3],
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="/courses/go/00_Basics/00_Loops/01_Check/00_For.prob.md">
+    <error line="3" severity="error" message="invalid front matter: points must not be negative, got -1" source="eocsutil.problem"></error>
+    <error line="7" severity="error" message="assets/x.png does not lead to an asset of the course" source="eocsutil.asset-link"></error>
+  </file>
+  <file name="/courses/go/00_Basics/index.yaml">
+    <error severity="warning" message="display_name, is: not&#xA;translated 100%" source="eocsutil.translation"></error>
+  </file>
+</checkstyle>
+`,
+		},
+		{
+			format:  "xml",
+			wantErr: `unknown diagnostics format "xml", it must be one of text, json, github, checkstyle`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if strings.HasPrefix(tt.rootDir, "/") && filepath.Separator != '/' {
+				t.Skip("the paths of the report are Unix paths")
+			}
+			buf := &bytes.Buffer{}
+			err := Write(buf, tt.format, tt.rootDir, tt.diags)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got the error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	diags := []Diagnostic{
+		Errorf("b.md", 2, "block", "b2"),
+		Errorf("a.md", 5, "block", "a5"),
+		Errorf("b.md", 1, "block", "b1 first"),
+		Errorf("b.md", 1, "block", "b1 second"),
+		Errorf("", 0, "course-settings", "course"),
+	}
+	Sort(diags)
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.Message)
+	}
+	want := "course, a5, b1 first, b1 second, b2"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestLineFromYAMLError(t *testing.T) {
+	tests := []struct {
+		err  string
+		want int
+	}{
+		{err: "yaml: line 3: mapping values are not allowed in this context", want: 3},
+		{err: "yaml: unmarshal errors:\n  line 12: field point not found in type eocs.ProblemMeta", want: 12},
+		{err: "yaml: did not find expected key", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			if got := LineFromYAMLError(errors.New(tt.err)); got != tt.want {
+				t.Errorf("got the line %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/diagnostics"
+	"github.com/exlskills/eocsutil/ir"
+	"github.com/exlskills/eocsutil/mdutils"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetsDirName is the folder of the course, or of a vertical, that holds the files that its blocks link to
+const assetsDirName = "assets"
+
+// olxStaticPrefix starts the links to the files of the static folder of an OLX course
+const olxStaticPrefix = "/static/"
+
+// Asset is a file of the assets folder of the course or of a vertical
+type Asset struct {
+	// Path is the path of the file within the assets folder, with forward slashes
+	Path string
+	// fsPath is where the file is read from when it is exported, the assets of other formats are held in contents
+	fsPath   string
+	contents []byte
+}
+
+func (asset *Asset) GetPath() string {
+	return asset.Path
+}
+
+func (asset *Asset) GetContents() ([]byte, error) {
+	if asset.fsPath == "" {
+		return asset.contents, nil
+	}
+	return ioutil.ReadFile(asset.fsPath)
+}
+
+func assetsToIRAssets(assets []*Asset) []ir.Asset {
+	irAssets := make([]ir.Asset, 0, len(assets))
+	for _, a := range assets {
+		irAssets = append(irAssets, a)
+	}
+	return irAssets
+}
+
+func irAssetsToAssets(assets []ir.Asset) ([]*Asset, error) {
+	eocsAssets := make([]*Asset, 0, len(assets))
+	for _, a := range assets {
+		if eocsA, ok := a.(*Asset); ok {
+			eocsAssets = append(eocsAssets, eocsA)
+			continue
+		}
+		contents, err := a.GetContents()
+		if err != nil {
+			return nil, err
+		}
+		eocsAssets = append(eocsAssets, &Asset{Path: a.GetPath(), contents: contents})
+	}
+	return eocsAssets, nil
+}
+
+// readAssets lists the files of the assets folder in dir, if there is one. Hidden files are left out
+func readAssets(dir string) (assets []*Asset, err error) {
+	assetsDir := filepath.Join(dir, assetsDirName)
+	if _, err := os.Stat(assetsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	err = filepath.Walk(assetsDir, func(fsPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fsPath != assetsDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(assetsDir, fsPath)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, &Asset{Path: filepath.ToSlash(relPath), fsPath: fsPath})
+		return nil
+	})
+	return assets, err
+}
+
+// writeAssets writes the assets into the assets folder in dir
+func writeAssets(dir string, assets []*Asset) error {
+	for _, a := range assets {
+		contents, err := a.GetContents()
+		if err != nil {
+			return err
+		}
+		fsPath := filepath.Join(dir, assetsDirName, filepath.FromSlash(a.Path))
+		err = os.MkdirAll(filepath.Dir(fsPath), 0775)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(fsPath, contents, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assetPaths returns the paths of the assets of the course relative to the course folder
+func (course *Course) assetPaths() map[string]bool {
+	paths := make(map[string]bool)
+	for _, a := range course.Assets {
+		paths[assetsDirName+"/"+a.Path] = true
+	}
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			for _, vert := range seq.Verticals {
+				for _, a := range vert.Assets {
+					paths[path.Join(filepath.ToSlash(vert.dir), assetsDirName, a.Path)] = true
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// mapMarkdownFiles replaces the markdown of each markdown and problem file of the course, translations included, with
+// what mapMD returns for it. fsPath is the path of the file in the course folder
+func mapMarkdownFiles(course *Course, mapMD func(fsPath, md string) string) {
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			for _, vert := range seq.Verticals {
+				mapVerticalMarkdownFiles(vert, mapMD)
+			}
+		}
+	}
+}
+
+func mapVerticalMarkdownFiles(vert *Vertical, mapMD func(fsPath, md string) string) {
+	for _, blk := range vert.Blocks {
+		if blk.BlockType != "html" && blk.BlockType != "problem" {
+			continue
+		}
+		fsPath := filepath.ToSlash(blk.FSPath)
+		blk.Markdown = mapMD(fsPath, blk.Markdown)
+		for locale, md := range blk.Translated {
+			blk.Translated[locale] = mapMD(translationFileName(fsPath, locale), md)
+		}
+	}
+}
+
+// resolveLink returns the path in the course folder that a relative link of a file in fromDir leads to, or false if
+// it leads out of the course
+func resolveLink(fromDir, ref string) (string, bool) {
+	p, _ := mdutils.SplitLink(ref)
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	target := path.Join(fromDir, p)
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "", false
+	}
+	return target, true
+}
+
+// isCourseImageAsset returns whether the course_image is one of the course assets rather than a URL or a file name of
+// the platform
+func (course *Course) isCourseImageAsset() bool {
+	return strings.HasPrefix(course.CourseImage, assetsDirName+"/")
+}
+
+// checkAssetLinks checks that every relative link of the blocks leads to an asset of the course, reporting all of those
+// that do not
+func checkAssetLinks(course *Course) (diags []diagnostics.Diagnostic) {
+	paths := course.assetPaths()
+	if course.isCourseImageAsset() && !paths[course.CourseImage] {
+		diags = append(diags, diagnostics.Errorf("index.yaml", 0, ruleAssetLink, "course_image %s is not an asset of the course", course.CourseImage))
+	}
+	mapMarkdownFiles(course, func(fsPath, md string) string {
+		// The links come in the order of the markdown, so each one is looked for after the previous one to find its line
+		offset := 0
+		mdutils.MapLinks(md, func(ref string) string {
+			if i := strings.Index(md[offset:], ref); i >= 0 {
+				offset += i
+			}
+			if !mdutils.IsRelativeLink(ref) {
+				return ref
+			}
+			if target, ok := resolveLink(path.Dir(fsPath), ref); !ok || !paths[target] {
+				line := strings.Count(md[:offset], "\n") + 1
+				diags = append(diags, diagnostics.Errorf(fsPath, line, ruleAssetLink, "%s does not lead to an asset of the course", ref))
+			}
+			return ref
+		})
+		return md
+	})
+	return diags
+}
+
+// useAssetBaseURL points the links to the assets of the course at where they are served from, which is
+// <base URL>/<course url_name>/<path in the course folder>. Without a base URL the links are left as they are
+func useAssetBaseURL(course *Course, baseURL string) {
+	paths := course.assetPaths()
+	linked := 0
+	toURL := func(fromDir, ref string) string {
+		if !mdutils.IsRelativeLink(ref) {
+			return ref
+		}
+		target, ok := resolveLink(fromDir, ref)
+		if !ok || !paths[target] {
+			return ref
+		}
+		linked++
+		if baseURL == "" {
+			return ref
+		}
+		_, suffix := mdutils.SplitLink(ref)
+		return assetURL(baseURL, course.URLName, target) + suffix
+	}
+	if course.isCourseImageAsset() {
+		course.CourseImage = toURL("", course.CourseImage)
+	}
+	mapMarkdownFiles(course, func(fsPath, md string) string {
+		return mdutils.MapLinks(md, func(ref string) string {
+			return toURL(path.Dir(fsPath), ref)
+		})
+	})
+	if baseURL == "" && linked > 0 {
+		Log.Warnf("ASSETS_BASE_URL is not set, so the %d links to the assets of course %s are loaded as relative paths", linked, course.URLName)
+	}
+}
+
+func assetURL(baseURL, courseURLName, target string) string {
+	segments := strings.Split(courseURLName+"/"+target, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+// linkStaticAssets turns the /static/ links of a course from OLX into relative links to the assets, in the folders that
+// the export puts them in: the files under static/<vertical url_name>/ belong to the vertical, the others to the course
+func linkStaticAssets(course *Course) {
+	courseAssets := make(map[string]bool, len(course.Assets))
+	for _, a := range course.Assets {
+		courseAssets[a.Path] = true
+	}
+	if courseAssets[course.CourseImage] {
+		course.CourseImage = assetsDirName + "/" + course.CourseImage
+	}
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			for _, vert := range seq.Verticals {
+				// The vertical is three directories below the course, plus one for each drafts folder on the way
+				depth := 3
+				for _, isDraft := range []bool{chap.IsDraft, seq.IsDraft, vert.IsDraft} {
+					if isDraft {
+						depth++
+					}
+				}
+				courseAssetsPrefix := strings.Repeat("../", depth) + assetsDirName + "/"
+				mapVerticalMarkdownFiles(vert, func(fsPath, md string) string {
+					return mdutils.MapLinks(md, func(ref string) string {
+						if !strings.HasPrefix(ref, olxStaticPrefix) {
+							return ref
+						}
+						p, suffix := mdutils.SplitLink(strings.TrimPrefix(ref, olxStaticPrefix))
+						if vertPath := strings.TrimPrefix(p, vert.URLName+"/"); vertPath != p {
+							for _, a := range vert.Assets {
+								if a.Path == vertPath {
+									return assetsDirName + "/" + vertPath + suffix
+								}
+							}
+						}
+						if courseAssets[p] {
+							return courseAssetsPrefix + p + suffix
+						}
+						return ref
+					})
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/diagnostics"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testAssetCourse returns a course with a course image, course assets and a vertical with assets of its own, whose
+// html block has the markdown md
+func testAssetCourse(md string) *Course {
+	vert := &Vertical{
+		URLName: "vert",
+		dir:     "00_Basics/00_Loops/00_For",
+		Assets:  []*Asset{{Path: "diagram.svg"}},
+		Blocks:  []*Block{{BlockType: "html", FSPath: "00_Basics/00_Loops/00_For/00_Text.md", Markdown: md}},
+	}
+	return &Course{
+		URLName:     "go course",
+		CourseImage: "assets/logo.png",
+		Assets:      []*Asset{{Path: "logo.png"}, {Path: "img/a b.png"}},
+		Chapters:    []*Chapter{{Sequentials: []*Sequential{{Verticals: []*Vertical{vert}}}}},
+	}
+}
+
+func TestReadAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eocs-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assets, err := readAssets(dir)
+	if err != nil || assets != nil {
+		t.Fatalf("got %v and the error %v for a folder without assets, want none", assets, err)
+	}
+	for _, name := range []string{"logo.png", "img/a.png", ".DS_Store", ".hidden/b.png", "img/.c.png"} {
+		path := filepath.Join(dir, assetsDirName, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assets, err = readAssets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, a := range assets {
+		paths = append(paths, a.GetPath())
+		contents, err := a.GetContents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != a.GetPath() {
+			t.Errorf("got the contents %q for %s", contents, a.GetPath())
+		}
+	}
+	want := []string{"img/a.png", "logo.png"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got the assets %v, want %v", paths, want)
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	tests := []struct {
+		fromDir string
+		ref     string
+		want    string
+		wantOK  bool
+	}{
+		{fromDir: "00_a/00_b/00_c", ref: "assets/x.png", want: "00_a/00_b/00_c/assets/x.png", wantOK: true},
+		{fromDir: "00_a/00_b/00_c", ref: "../../../assets/x.png?v=2#top", want: "assets/x.png", wantOK: true},
+		{fromDir: "00_a/00_b/00_c", ref: "assets/a%20b.png", want: "00_a/00_b/00_c/assets/a b.png", wantOK: true},
+		{fromDir: "00_a/00_b/00_c", ref: "../../../../x.png"},
+		{fromDir: "", ref: "../x.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, ok := resolveLink(tt.fromDir, tt.ref)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckAssetLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		md          string
+		courseImage string
+		want        []diagnostics.Diagnostic
+	}{
+		{
+			name: "links to assets, URLs and anchors",
+			md:   "![Diagram](assets/diagram.svg)\n\n![Logo](../../../assets/logo.png \"Logo\")\n\n[Go](https://golang.org) [Top](#top) [Home](/home)\n\n<img src=\"../../../assets/img/a%20b.png\">\n",
+		},
+		{
+			name: "links in code are not checked",
+			md:   "`![x](assets/missing.png)`\n\n```\n![x](assets/missing.png)\n```\n",
+		},
+		{
+			name: "links that do not lead to an asset",
+			md:   "# Loops\n\n![Diagram](assets/diagram.png)\n\nSee [the logo](../../../assets/logo.png) and\n[this](../../../../outside.png)\n",
+			want: []diagnostics.Diagnostic{
+				diagnostics.Errorf("00_Basics/00_Loops/00_For/00_Text.md", 3, ruleAssetLink, "assets/diagram.png does not lead to an asset of the course"),
+				diagnostics.Errorf("00_Basics/00_Loops/00_For/00_Text.md", 6, ruleAssetLink, "../../../../outside.png does not lead to an asset of the course"),
+			},
+		},
+		{
+			name:        "a course image that is not an asset",
+			courseImage: "assets/cover.png",
+			want: []diagnostics.Diagnostic{
+				diagnostics.Errorf("index.yaml", 0, ruleAssetLink, "course_image assets/cover.png is not an asset of the course"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			course := testAssetCourse(tt.md)
+			if tt.courseImage != "" {
+				course.CourseImage = tt.courseImage
+			}
+			got := checkAssetLinks(course)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUseAssetBaseURL(t *testing.T) {
+	md := "![Diagram](assets/diagram.svg#part) ![Logo](../../../assets/img/a%20b.png) [Go](https://golang.org) ![x](assets/missing.png)\n"
+	tests := []struct {
+		name            string
+		baseURL         string
+		wantMD          string
+		wantCourseImage string
+	}{
+		{
+			name:            "links are left relative without a base URL",
+			wantMD:          md,
+			wantCourseImage: "assets/logo.png",
+		},
+		{
+			name:            "links point at the base URL",
+			baseURL:         "https://cdn.example.com/courses/",
+			wantMD:          "![Diagram](https://cdn.example.com/courses/go%20course/00_Basics/00_Loops/00_For/assets/diagram.svg#part) ![Logo](https://cdn.example.com/courses/go%20course/assets/img/a%20b.png) [Go](https://golang.org) ![x](assets/missing.png)\n",
+			wantCourseImage: "https://cdn.example.com/courses/go%20course/assets/logo.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			course := testAssetCourse(md)
+			useAssetBaseURL(course, tt.baseURL)
+			got := course.Chapters[0].Sequentials[0].Verticals[0].Blocks[0].Markdown
+			if got != tt.wantMD {
+				t.Errorf("got the markdown\n%s\nwant\n%s", got, tt.wantMD)
+			}
+			if course.CourseImage != tt.wantCourseImage {
+				t.Errorf("got the course image %s, want %s", course.CourseImage, tt.wantCourseImage)
+			}
+		})
+	}
+}
+
+func TestLinkStaticAssets(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      string
+		isDraft bool
+		want    string
+	}{
+		{
+			name: "assets of the course and of the vertical",
+			md:   "![Logo](/static/logo.png) ![Diagram](/static/vert/diagram.svg?v=1) ![x](/static/missing.png)\n",
+			want: "![Logo](../../../assets/logo.png) ![Diagram](assets/diagram.svg?v=1) ![x](/static/missing.png)\n",
+		},
+		{
+			name:    "a draft vertical is a folder further down",
+			md:      "![Logo](/static/logo.png)\n",
+			isDraft: true,
+			want:    "![Logo](../../../../assets/logo.png)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			course := testAssetCourse(tt.md)
+			course.CourseImage = "logo.png"
+			vert := course.Chapters[0].Sequentials[0].Verticals[0]
+			vert.IsDraft = tt.isDraft
+			linkStaticAssets(course)
+			if vert.Blocks[0].Markdown != tt.want {
+				t.Errorf("got the markdown\n%s\nwant\n%s", vert.Blocks[0].Markdown, tt.want)
+			}
+			if course.CourseImage != "assets/logo.png" {
+				t.Errorf("got the course image %s, want assets/logo.png", course.CourseImage)
+			}
+		})
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/exlskills/eocsutil/wsenv"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 )
 
@@ -31,6 +30,7 @@ func appendIRBlocksToVertical(vert *Vertical, blocks []ir.Block) (err error) {
 			URLName:     b.GetURLName(),
 			DisplayName: b.GetDisplayName(),
 			FSPath:      b.GetExtraAttributes()["fs_path"],
+			Translated:  blockTranslationsFromExtraAttributes(b.GetExtraAttributes()),
 		}
 		if newB.BlockType == "problem" {
 			md, err := b.GetContentMD()
@@ -38,6 +38,14 @@ func appendIRBlocksToVertical(vert *Vertical, blocks []ir.Block) (err error) {
 				return err
 			}
 			newB.Markdown = md
+			newB.Meta, err = problemMetaFromExtraAttributes(b.GetExtraAttributes())
+			if err != nil {
+				return err
+			}
+			if eocsB, ok := b.(*Block); ok {
+				// Problems coming from another EOCS course (e.g. one read from MongoDB) carry their REPL along
+				newB.REPL = eocsB.REPL
+			}
 		} else if newB.BlockType == "exleditor" {
 			err = newB.UnmarshalREPLFromOLX(b.GetExtraAttributes()["editor_config"])
 			if err != nil {
@@ -72,6 +80,10 @@ type Block struct {
 	Markdown    string     `yaml:"-"`
 	REPL        *BlockREPL `yaml:"-"`
 	FSPath      string     `yaml:"-"`
+	// Meta is the front matter of a problem, which is not part of its Markdown
+	Meta *ProblemMeta `yaml:"-"`
+	// Translated holds the overlay files of the block by locale
+	Translated map[string]string `yaml:"-"`
 }
 
 func (block *Block) GetDisplayName() string {
@@ -101,9 +113,16 @@ func (block *Block) GetContentOLX() (string, error) {
 }
 
 func (block *Block) GetExtraAttributes() map[string]string {
-	return map[string]string{
+	attrs := map[string]string{
 		"fs_path": block.FSPath,
 	}
+	if block.Meta != nil {
+		for k, v := range block.Meta.extraAttributes() {
+			attrs[k] = v
+		}
+	}
+	blockTranslationExtraAttributes(block.Translated, attrs)
+	return attrs
 }
 
 func (block *Block) UnmarshalREPLFromOLX(cfgJSON string) (err error) {
@@ -126,6 +145,9 @@ func (block *Block) UnmarshalREPLFromOLX(cfgJSON string) (err error) {
 func (block *Block) MarshalREPL(rootDir, baseName string) (err error) {
 	block.REPL.SourcePath = filepath.Join(".", baseName+".repl", "src")
 	block.REPL.TestPath = filepath.Join(".", baseName+".repl", "test")
+	if block.REPL.TmplFiles != nil {
+		block.REPL.TmplPath = filepath.Join(".", baseName+".repl", "tmpl")
+	}
 	outYAML, err := yaml.Marshal(block.REPL)
 	if err != nil {
 		return err
@@ -135,34 +157,18 @@ func (block *Block) MarshalREPL(rootDir, baseName string) (err error) {
 		return err
 	}
 
+	// The files are kept in the layout of the environment, while the REPL directories only hold what is inside of it
+	envKey := block.REPL.EnvironmentKey
 	filesDir := filepath.Join(rootDir, baseName+".repl")
-	err = os.MkdirAll(filesDir, 0755)
-	if err != nil {
-		return err
-	}
-
-	srcDir := filepath.Join(filesDir, "src")
-	err = os.MkdirAll(srcDir, 0755)
-	if err != nil {
-		return err
-	}
-	for name, file := range block.REPL.SrcFiles {
-		err = ioutil.WriteFile(filepath.Join(srcDir, name), []byte(file.Contents), 0755)
-		if err != nil {
-			return err
-		}
-	}
-
-	testDir := filepath.Join(filesDir, "test")
-	err = os.MkdirAll(testDir, 0755)
+	err = writeFilesToDirRecursive(filepath.Join(filesDir, "src"), unwrapFilesForEnv(envKey, block.REPL.SrcFiles))
 	if err != nil {
 		return err
 	}
-	for name, file := range block.REPL.TestFiles {
-		err = ioutil.WriteFile(filepath.Join(testDir, name), []byte(file.Contents), 0755)
+	if block.REPL.TmplFiles != nil {
+		err = writeFilesToDirRecursive(filepath.Join(filesDir, "tmpl"), unwrapFilesForEnv(envKey, block.REPL.TmplFiles))
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return writeFilesToDirRecursive(filepath.Join(filesDir, "test"), unwrapFilesForEnv(envKey, block.REPL.TestFiles))
 }
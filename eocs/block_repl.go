@@ -4,10 +4,14 @@ import (
 	"github.com/exlskills/eocsutil/wsenv"
 	"github.com/pkg/errors"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+var problemREPLShebangRegex = regexp.MustCompile(`#!exl::repl\('[^']*'\)`)
+
 var validEnvKeys = map[string]struct{}{
 	"java_default_free": {},
 	"python_2_7_free":   {},
@@ -124,6 +128,26 @@ func loadFilesFromFSForEnv(envKey, dir string) (files map[string]*wsenv.Workspac
 	}
 }
 
+// unwrapFilesForEnv is the reverse of loadFilesFromFSForEnv, it strips the environment specific directories that were
+// wrapped around the files of the REPL directory
+func unwrapFilesForEnv(envKey string, files map[string]*wsenv.WorkspaceFile) map[string]*wsenv.WorkspaceFile {
+	switch envKey {
+	case "java_default_free":
+		dir := files
+		for _, name := range []string{"src", "main", "java", "exlcode"} {
+			if f, ok := dir[name]; ok && f.IsDir {
+				dir = f.Children
+			} else {
+				// Not the layout produced by loadFilesFromFSForEnv, so keep the files as they are
+				return files
+			}
+		}
+		return dir
+	default:
+		return files
+	}
+}
+
 func loadFilesFromDirRecursive(dir string) (files map[string]*wsenv.WorkspaceFile, err error) {
 	dirListing, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -155,6 +179,24 @@ func loadFilesFromDirRecursive(dir string) (files map[string]*wsenv.WorkspaceFil
 	return files, nil
 }
 
+func writeFilesToDirRecursive(dir string, files map[string]*wsenv.WorkspaceFile) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for name, file := range files {
+		if file.IsDir {
+			err = writeFilesToDirRecursive(filepath.Join(dir, name), file.Children)
+		} else {
+			err = ioutil.WriteFile(filepath.Join(dir, name), []byte(file.Contents), 0755)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func isValidProblemREPLShebang(sb string) bool {
 	if strings.HasPrefix(sb, "#!exl::repl('") && strings.HasSuffix(sb, "')") {
 		return true
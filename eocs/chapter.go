@@ -29,6 +29,13 @@ func appendIRChaptersToCourse(course *Course, chaps []ir.Chapter) (err error) {
 				URLName:     c.GetURLName(),
 				DisplayName: c.GetDisplayName(),
 			}
+			newC.OutlineInfo, err = outlineInfoFromExtraAttributes(c.GetExtraAttributes())
+			if err != nil {
+				errsChan <- err
+				return
+			}
+			newC.Translated = translationsFromExtraAttributes(c.GetExtraAttributes())
+			newC.IsDraft = c.GetIsDraft()
 			err = appendIRSequentialsToChapter(newC, c.GetSequentials())
 			if err != nil {
 				errsChan <- err
@@ -59,6 +66,13 @@ type Chapter struct {
 	DisplayName string        `yaml:"display_name"`
 	Sequentials []*Sequential `yaml:"-"`
 	UpdatedAt   time.Time     `yaml:"-"`
+	CreatedAt   time.Time     `yaml:"-"`
+	OutlineInfo `yaml:",inline"`
+	Translated  map[string]*Translation `yaml:"-"`
+	// dir is the directory of the chapter relative to the course, for naming its index.yaml in reports
+	dir string
+	// IsDraft is set for the chapters of a drafts folder, which are left out of a push unless drafts are included
+	IsDraft bool `yaml:"-"`
 }
 
 func (chap *Chapter) GetDisplayName() string {
@@ -69,8 +83,14 @@ func (chap *Chapter) GetURLName() string {
 	return chap.URLName
 }
 
+func (chap *Chapter) GetIsDraft() bool {
+	return chap.IsDraft
+}
+
 func (chap *Chapter) GetExtraAttributes() map[string]string {
-	return map[string]string{}
+	attrs := chap.OutlineInfo.extraAttributes()
+	translationExtraAttributes(chap.Translated, attrs)
+	return attrs
 }
 
 func (chap *Chapter) GetSequentials() []ir.Sequential {
@@ -80,3 +100,7 @@ func (chap *Chapter) GetSequentials() []ir.Sequential {
 func (chap *Chapter) SetUpdatedAt(updatedAt time.Time) {
 	chap.UpdatedAt = updatedAt
 }
+
+func (chap *Chapter) SetCreatedAt(createdAt time.Time) {
+	chap.CreatedAt = createdAt
+}
@@ -2,29 +2,19 @@ package eocs
 
 import (
 	"bytes"
-	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/exlskills/eocsutil/config"
+	"github.com/exlskills/eocsutil/diagnostics"
 	"github.com/exlskills/eocsutil/eocs/esmodels"
 	"github.com/exlskills/eocsutil/ir"
-	"github.com/exlskills/eocsutil/mdutils"
 	"github.com/exlskills/eocsutil/olx/olxproblems"
 	"github.com/exlskills/eocsutil/wsenv"
-	"github.com/globalsign/mgo"
-	"github.com/globalsign/mgo/bson"
-	"github.com/olivere/elastic"
 	"github.com/remeh/sizedwaitgroup"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"math"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -38,42 +28,94 @@ type parserCtx struct {
 	vertIdx int
 	n       int
 	swg     *sizedwaitgroup.SizedWaitGroup
+	// readOnly keeps the assigned url_names in memory only, reporting the directories instead of writing their index.yaml
+	readOnly bool
+	rootDir  string
+	// diags are the problems found in the course so far
+	diags      []diagnostics.Diagnostic
+	diagsMutex sync.Mutex
 }
 
-var olxProblemChoiceHintsMdRegex = regexp.MustCompile(`(?s-i)<choicehint.+?<\/choicehint>`)
+// resolveCourseRecursive reads the course in rootDir, failing with all of the errors found in it. The warnings are
+// logged
+func resolveCourseRecursive(rootDir string, readOnly bool) (*Course, error) {
+	c, diags := resolveCourse(rootDir, readOnly)
+	var errs []string
+	for _, d := range diags {
+		if d.Severity == diagnostics.SeverityError {
+			errs = append(errs, d.String())
+		} else {
+			Log.Warn(d.String())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(fmt.Sprintf("%d problems found in the course in %s:\n  %s", len(errs), rootDir, strings.Join(errs, "\n  ")))
+	}
+	return c, nil
+}
 
-func resolveCourseRecursive(rootDir string) (*Course, error) {
+// resolveCourse reads the course in rootDir, going on past the problems that it finds so that all of them are reported.
+// The course is nil if its index.yaml cannot be read
+func resolveCourse(rootDir string, readOnly bool) (*Course, []diagnostics.Diagnostic) {
 	Log.Infof("Root Directory %s", rootDir)
 	rootCourseYAML, err := getIndexYAML(rootDir)
 	if err != nil {
-		return nil, err
+		return nil, []diagnostics.Diagnostic{diagnostics.Errorf("index.yaml", 0, ruleIndexYAML, "%s", err.Error())}
 	}
 	c := &Course{}
 	err = yaml.Unmarshal(rootCourseYAML, c)
 	if err != nil {
-		return nil, err
+		return nil, []diagnostics.Diagnostic{diagnostics.Errorf("index.yaml", diagnostics.LineFromYAMLError(err), ruleIndexYAML, "%s", err.Error())}
 	}
 	swgV := sizedwaitgroup.New(5)
 	pcx := &parserCtx{
-		course:  c,
-		chapIdx: -1,
-		seqIdx:  -1,
-		vertIdx: -1,
-		n:       0,
-		swg:     &swgV,
+		course:   c,
+		chapIdx:  -1,
+		seqIdx:   -1,
+		vertIdx:  -1,
+		n:        0,
+		swg:      &swgV,
+		readOnly: readOnly,
+		rootDir:  rootDir,
+	}
+	err = c.validateSettings()
+	if err != nil {
+		pcx.reportErr(ruleCourseSettings, "index.yaml", err)
 	}
-	err = filepath.Walk(rootDir, courseWalkFunc(rootDir, pcx))
+	err = c.validateTranslations()
 	if err != nil {
-		return nil, err
+		pcx.reportErr(ruleTranslation, "index.yaml", err)
+		// The overlays are not read for locales that are not valid
+		c.TranslationLocales = nil
 	}
+	c.Translated, err = readTranslations(rootDir, c.TranslationLocales)
+	if err != nil {
+		pcx.reportErr(ruleTranslation, "", err)
+	}
+	c.Assets, err = readAssets(rootDir)
+	if err != nil {
+		pcx.reportErr(ruleAssets, assetsDirName, err)
+	}
+	err = filepath.Walk(rootDir, courseWalkFunc(rootDir, pcx))
 	Log.Info("Returned from course directory scanning. Waiting for workers to return ...")
 	pcx.swg.Wait()
 	Log.Info("All course content workers returned.")
-	err = checkWalkErrors(*c)
 	if err != nil {
-		return nil, err
+		// Only writing the assigned IDs stops the walk
+		pcx.reportErr(ruleIndexYAML, "", err)
+		return nil, pcx.diags
+	}
+	pcx.report(orderOutline(c)...)
+	pcx.report(checkAssetLinks(c)...)
+	// Checks the exam settings that span the sequentials
+	_, err = finalExamWeights(c)
+	if err != nil {
+		pcx.reportErr(ruleExamSettings, "", err)
 	}
-	return c, nil
+	logUntranslated(c)
+	// The blocks are read concurrently, so the diagnostics are put in a stable order
+	diagnostics.Sort(pcx.diags)
+	return c, pcx.diags
 }
 
 func courseWalkFunc(rootDir string, pcx *parserCtx) filepath.WalkFunc {
@@ -97,40 +139,58 @@ func courseWalkFunc(rootDir string, pcx *parserCtx) filepath.WalkFunc {
 		if isIgnoredDir(base) {
 			return filepath.SkipDir
 		}
-		pathParts := strings.Split(strings.Replace(path, rootDir+string(filepath.Separator), "", 1), string(filepath.Separator))
+		if base == draftsDirName {
+			// Its chapters, sequentials or verticals are read along with those of its parent, which orderOutline puts them after
+			return nil
+		}
+		relPath := strings.Replace(path, rootDir+string(filepath.Separator), "", 1)
+		pathParts, isDraft := outlinePathParts(relPath)
+		_, dispName, err := indexAndNameFromConcatenated(base)
+		if err != nil {
+			// Without a valid name the directory cannot be placed in the course, nor can its contents
+			pcx.reportErr(ruleDirName, relPath, err)
+			return filepath.SkipDir
+		}
 		if len(pathParts) == 1 {
 			// Create a new chapter
 			pcx.vertIdx = -1
 			pcx.seqIdx = -1
 			pcx.chapIdx++
 			chap := &Chapter{}
-			_, dispName, err := indexAndNameFromConcatenated(base)
-			if err != nil {
-				return err
-			}
 			if indxBytes, err := getIndexYAML(path); err == nil {
 				err = yaml.Unmarshal(indxBytes, &chap)
 				if err != nil {
-					return err
+					pcx.report(diagnostics.Errorf(indexYAMLPath(relPath), diagnostics.LineFromYAMLError(err), ruleIndexYAML, "%s", err.Error()))
 				}
 				dispName = chap.DisplayName
-				if chap.URLName == "" {
-					chap.URLName = esmodels.ESID()
+				// An index.yaml that failed to parse is not written over
+				if err == nil && chap.URLName == "" {
+					chap.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 					// Persist the ID
-					err := writeIndexYAML(path, chap)
+					err := pcx.persistAssignedID(path, chap)
 					if err != nil {
 						return err
 					}
 				}
 			} else {
-				chap.URLName = esmodels.ESID()
+				chap.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 				chap.DisplayName = dispName
 				// Persist the ID
-				err := writeIndexYAML(path, chap)
+				err := pcx.persistAssignedID(path, chap)
 				if err != nil {
 					return err
 				}
 			}
+			err = chap.OutlineInfo.validate(relPath)
+			if err != nil {
+				pcx.reportErr(ruleOutlineSettings, indexYAMLPath(relPath), err)
+			}
+			chap.dir = relPath
+			chap.IsDraft = isDraft
+			chap.Translated, err = readTranslations(path, pcx.course.TranslationLocales)
+			if err != nil {
+				pcx.reportErr(ruleTranslation, relPath, err)
+			}
 			chap.Index = pcx.chapIdx
 			pcx.course.Chapters = append(pcx.course.Chapters, chap)
 		} else if len(pathParts) == 2 {
@@ -139,68 +199,90 @@ func courseWalkFunc(rootDir string, pcx *parserCtx) filepath.WalkFunc {
 			pcx.vertIdx = -1
 			pcx.seqIdx++
 			seq := &Sequential{}
-			_, dispName, err := indexAndNameFromConcatenated(base)
-			if err != nil {
-				return err
-			}
 			if indxBytes, err := getIndexYAML(path); err == nil {
 				err = yaml.Unmarshal(indxBytes, &seq)
 				if err != nil {
-					return err
+					pcx.report(diagnostics.Errorf(indexYAMLPath(relPath), diagnostics.LineFromYAMLError(err), ruleIndexYAML, "%s", err.Error()))
 				}
 				dispName = seq.DisplayName
-				if seq.URLName == "" {
-					seq.URLName = esmodels.ESID()
+				// An index.yaml that failed to parse is not written over
+				if err == nil && seq.URLName == "" {
+					seq.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 					// Persist the ID
-					err := writeIndexYAML(path, seq)
+					err := pcx.persistAssignedID(path, seq)
 					if err != nil {
 						return err
 					}
 				}
 			} else {
-				seq.URLName = esmodels.ESID()
+				seq.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 				seq.DisplayName = dispName
 				// Persist the ID
-				err := writeIndexYAML(path, seq)
+				err := pcx.persistAssignedID(path, seq)
 				if err != nil {
 					return err
 				}
 			}
+			seq.dir = relPath
+			seq.IsDraft = isDraft
+			err = seq.validateExamConfig()
+			if err != nil {
+				pcx.reportErr(ruleExamSettings, indexYAMLPath(relPath), err)
+			}
+			err = seq.OutlineInfo.validate(relPath)
+			if err != nil {
+				pcx.reportErr(ruleOutlineSettings, indexYAMLPath(relPath), err)
+			}
+			seq.Translated, err = readTranslations(path, pcx.course.TranslationLocales)
+			if err != nil {
+				pcx.reportErr(ruleTranslation, relPath, err)
+			}
 			pcx.course.Chapters[pcx.chapIdx].Sequentials = append(pcx.course.Chapters[pcx.chapIdx].Sequentials, seq)
 		} else if len(pathParts) == 3 {
 			// Create an index a new vertical
 			pcx.vertIdx++
 			vert := &Vertical{}
-			_, dispName, err := indexAndNameFromConcatenated(base)
-			if err != nil {
-				return err
-			}
 			Log.Debug("Adding vertical: ", dispName)
 			if indxBytes, err := getIndexYAML(path); err == nil {
 				err = yaml.Unmarshal(indxBytes, &vert)
 				if err != nil {
-					return err
+					pcx.report(diagnostics.Errorf(indexYAMLPath(relPath), diagnostics.LineFromYAMLError(err), ruleIndexYAML, "%s", err.Error()))
 				}
 				dispName = vert.DisplayName
-				if vert.URLName == "" {
-					vert.URLName = esmodels.ESID()
+				// An index.yaml that failed to parse is not written over
+				if err == nil && vert.URLName == "" {
+					vert.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 					// Persist the ID
-					err := writeIndexYAML(path, vert)
+					err := pcx.persistAssignedID(path, vert)
 					if err != nil {
 						return err
 					}
 				}
 			} else {
-				vert.URLName = esmodels.ESID()
+				vert.URLName = esmodels.StableESID(pcx.course.URLName, filepath.ToSlash(relPath))
 				vert.DisplayName = dispName
 				// Persist the ID
-				err := writeIndexYAML(path, vert)
+				err := pcx.persistAssignedID(path, vert)
 				if err != nil {
 					return err
 				}
 			}
+			err = vert.OutlineInfo.validate(relPath)
+			if err != nil {
+				pcx.reportErr(ruleOutlineSettings, indexYAMLPath(relPath), err)
+			}
+			vert.dir = relPath
+			vert.IsDraft = isDraft
+			vert.Translated, err = readTranslations(path, pcx.course.TranslationLocales)
+			if err != nil {
+				pcx.reportErr(ruleTranslation, relPath, err)
+			}
+			vert.Assets, err = readAssets(path)
+			if err != nil {
+				pcx.reportErr(ruleAssets, filepath.Join(relPath, assetsDirName), err)
+			}
 			pcx.swg.Add()
-			go blockExtractionRoutine(pcx.swg, vert, path)
+			go blockExtractionRoutine(pcx.swg, pcx, vert, path, pcx.course.TranslationLocales)
 			for _, b := range vert.Blocks {
 				Log.Debugf("After blockExtractionRoutine. Block type %s, path  %s", b.BlockType, b.FSPath)
 			}
@@ -208,113 +290,145 @@ func courseWalkFunc(rootDir string, pcx *parserCtx) filepath.WalkFunc {
 			// Since the vertical directory was handled by the 'extractBlocks' func above, we want to keep moving...
 			return filepath.SkipDir
 		} else {
-			Log.Println(pathParts)
-			Log.Println(pcx)
-			return errors.New("eocs: invalid directory depth/name combination")
+			pcx.report(diagnostics.Errorf(relPath, 0, ruleDirDepth, "eocs: invalid directory depth/name combination"))
+			return filepath.SkipDir
 		}
 		return nil
 	}
 }
 
-func blockExtractionRoutine(wg *sizedwaitgroup.SizedWaitGroup, vert *Vertical, path string) {
-	defer wg.Done()
-	var err error
-	vert.Blocks, err = extractBlocksFromVerticalDirectory(path)
-	if err != nil {
-		// Log.Fatalf("Encountered fatal error processing blocks for vertical %s (ID: %s), error: %s", vert.DisplayName, vert.URLName, err.Error())
-		// Need to ensure a clean program exit as well as continue validation
-		// Append a dummy ERROR Block
-		vert.Blocks = append(vert.Blocks, &Block{
-			BlockType: "ERROR",
-			Markdown:  fmt.Sprintf("%v", err),
-		})
+// persistAssignedID writes the index.yaml of a directory that was just assigned a url_name, unless the import is read-only
+func (pcx *parserCtx) persistAssignedID(path string, object interface{}) error {
+	if pcx.readOnly {
+		pcx.report(diagnostics.Warnf(indexYAMLPath(path), 0, ruleURLName, "the url_name is missing, the ID assigned to the directory is kept in memory only unless --write-ids is given"))
+		return nil
 	}
+	return writeIndexYAML(path, object)
 }
 
-func extractBlocksFromVerticalDirectory(rootPath string) (blks []*Block, err error) {
-	rootPathParts := strings.Split(rootPath, "/")
-	if len(rootPathParts) < 4 {
-		return nil, errors.New("invalid path to block, must contain at least 4 directories (course->chapter->sequential->vertical) to form valid EOCS structure")
-	}
-	rootPathParts = rootPathParts[len(rootPathParts)-3:]
+func blockExtractionRoutine(wg *sizedwaitgroup.SizedWaitGroup, pcx *parserCtx, vert *Vertical, path string, locales []string) {
+	defer wg.Done()
+	var diags []diagnostics.Diagnostic
+	vert.Blocks, diags = extractBlocksFromVerticalDirectory(path, vert.dir, vert.URLName, locales)
+	pcx.report(diags...)
+}
+
+// extractBlocksFromVerticalDirectory reads the blocks of a vertical, along with the overlays of the given locales. relPath
+// is the directory of the vertical relative to the course, which the FSPath of the blocks starts with. The files that
+// fail to read are reported and left out
+func extractBlocksFromVerticalDirectory(rootPath, relPath, vertURLName string, locales []string) (blks []*Block, diags []diagnostics.Diagnostic) {
 	vertDirListing, err := ioutil.ReadDir(rootPath)
 	if err != nil {
-		return nil, err
+		return nil, []diagnostics.Diagnostic{diagnostics.Errorf(filepath.ToSlash(relPath), 0, ruleBlock, "%s", err.Error())}
 	}
 	// We ignore all directories here, until they become explicitly imported by a repl or other method
 	for _, fi := range vertDirListing {
-		if strings.HasSuffix(fi.Name(), ".prob.md") {
-			// Parse as `problem` block
-			byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, fi.Name()))
-			if err != nil {
-				return nil, err
-			}
-			prob, err := olxproblems.NewProblemFromMD(string(byteContents))
-			if err != nil {
-				Log.Error("Encountered error in file: ", filepath.Join(rootPath, fi.Name()))
-				return nil, err
-			}
-			var rpl *BlockREPL
-			if prob.StringResponse != nil && strings.HasPrefix(prob.StringResponse.Answer, "#!") {
-				// Start looking for the REPL
-				yamlName, err := getProblemREPLPath(prob.StringResponse.Answer)
-				if err != nil {
-					return nil, err
-				}
-				rplYamlContents, err := ioutil.ReadFile(filepath.Join(rootPath, yamlName))
-				if err != nil {
-					return nil, err
-				}
-				rpl, err = loadReplForEOCS(rplYamlContents, rootPath)
-				if err != nil {
-					return nil, err
-				}
-			}
-			blks = append(blks, &Block{
-				BlockType: "problem",
-				// NOTE: This URLName is not actually used in the EXLskills import, so it is okay to set it on each load...
-				URLName:     esmodels.ESID(),
-				DisplayName: strings.SplitN(fi.Name(), ".", 2)[0],
-				Markdown:    string(byteContents),
-				REPL:        rpl,
-				FSPath:      filepath.Join(append(rootPathParts, fi.Name())...),
-			})
-		} else if strings.HasSuffix(fi.Name(), ".md") {
-			// Parse as `html` block
-			byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, fi.Name()))
+		if isTranslationFile(fi.Name(), locales) {
+			// Read along with the file that it translates
+			continue
+		}
+		blk, err := extractBlock(rootPath, relPath, vertURLName, fi.Name(), len(blks), locales)
+		if err != nil {
+			diags = append(diags, blockDiagnostic(filepath.Join(relPath, fi.Name()), err))
+			continue
+		}
+		if blk != nil {
+			blks = append(blks, blk)
+		}
+	}
+	return blks, diags
+}
+
+// extractBlock reads the block of the file of a vertical, returning nil if the file is not one
+func extractBlock(rootPath, relPath, vertURLName, name string, position int, locales []string) (*Block, error) {
+	if strings.HasSuffix(name, ".prob.md") {
+		// Parse as `problem` block
+		byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, name))
+		if err != nil {
+			return nil, err
+		}
+		meta, probMD, err := splitProblemFrontMatter(string(byteContents))
+		if err != nil {
+			return nil, err
+		}
+		prob, err := olxproblems.NewProblemFromMD(probMD)
+		if err != nil {
+			Log.Error("Encountered error in file: ", filepath.Join(rootPath, name))
+			return nil, err
+		}
+		var rpl *BlockREPL
+		if prob.StringResponse != nil && strings.HasPrefix(prob.StringResponse.Answer, "#!") {
+			// Start looking for the REPL
+			yamlName, err := getProblemREPLPath(prob.StringResponse.Answer)
 			if err != nil {
 				return nil, err
 			}
-			blks = append(blks, &Block{
-				BlockType: "html",
-				// NOTE: This URLName is not actually used in the EXLskills import, so it is okay to set it on each load...
-				URLName:     esmodels.ESID(),
-				DisplayName: strings.SplitN(fi.Name(), ".", 2)[0],
-				Markdown:    string(byteContents),
-				FSPath:      filepath.Join(append(rootPathParts, fi.Name())...),
-			})
-		} else if strings.HasSuffix(fi.Name(), ".repl.yaml") && !strings.HasSuffix(fi.Name(), ".prob.repl.yaml") {
-			// Parse as `exleditor` block
-			byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, fi.Name()))
+			rplYamlContents, err := ioutil.ReadFile(filepath.Join(rootPath, yamlName))
 			if err != nil {
 				return nil, err
 			}
-			var rpl *BlockREPL
-			rpl, err = loadReplForEOCS(byteContents, rootPath)
+			rpl, err = loadReplForEOCS(rplYamlContents, rootPath)
 			if err != nil {
-				return nil, err
+				return nil, &fileError{path: filepath.Join(relPath, yamlName), line: diagnostics.LineFromYAMLError(err), err: err}
 			}
-			blks = append(blks, &Block{
-				BlockType: "exleditor",
-				// NOTE: This URLName is not actually used in the EXLskills import, so it is okay to set it on each load...
-				URLName:     esmodels.ESID(),
-				DisplayName: strings.SplitN(fi.Name(), ".", 2)[0],
-				REPL:        rpl,
-				FSPath:      filepath.Join(append(rootPathParts, fi.Name())...),
-			})
 		}
-	}
-	return
+		translated, err := readBlockTranslations(rootPath, name, locales, true)
+		if err != nil {
+			return nil, err
+		}
+		return &Block{
+			BlockType:   "problem",
+			URLName:     blockURLName(vertURLName, name, position),
+			DisplayName: strings.SplitN(name, ".", 2)[0],
+			Markdown:    probMD,
+			REPL:        rpl,
+			FSPath:      filepath.Join(relPath, name),
+			Meta:        meta,
+			Translated:  translated,
+		}, nil
+	} else if strings.HasSuffix(name, ".md") {
+		// Parse as `html` block
+		byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, name))
+		if err != nil {
+			return nil, err
+		}
+		translated, err := readBlockTranslations(rootPath, name, locales, false)
+		if err != nil {
+			return nil, err
+		}
+		return &Block{
+			BlockType:   "html",
+			URLName:     blockURLName(vertURLName, name, position),
+			DisplayName: strings.SplitN(name, ".", 2)[0],
+			Markdown:    string(byteContents),
+			FSPath:      filepath.Join(relPath, name),
+			Translated:  translated,
+		}, nil
+	} else if strings.HasSuffix(name, ".repl.yaml") && !strings.HasSuffix(name, ".prob.repl.yaml") {
+		// Parse as `exleditor` block
+		byteContents, err := ioutil.ReadFile(filepath.Join(rootPath, name))
+		if err != nil {
+			return nil, err
+		}
+		var rpl *BlockREPL
+		rpl, err = loadReplForEOCS(byteContents, rootPath)
+		if err != nil {
+			return nil, &fileError{path: filepath.Join(relPath, name), line: diagnostics.LineFromYAMLError(err), err: err}
+		}
+		return &Block{
+			BlockType:   "exleditor",
+			URLName:     blockURLName(vertURLName, name, position),
+			DisplayName: strings.SplitN(name, ".", 2)[0],
+			REPL:        rpl,
+			FSPath:      filepath.Join(relPath, name),
+		}, nil
+	}
+	return nil, nil
+}
+
+// blockURLName derives the URLName of a block from its vertical, file name and position, so it is the same on every load
+func blockURLName(vertURLName, fileName string, position int) string {
+	return esmodels.StableESID(vertURLName, fileName, strconv.Itoa(position))
 }
 
 func loadReplForEOCS(yamlBytes []byte, rootPath string) (rpl *BlockREPL, err error) {
@@ -335,141 +449,218 @@ func loadReplForEOCS(yamlBytes []byte, rootPath string) (rpl *BlockREPL, err err
 	return rpl, nil
 }
 
-// upsertCourseRecursive handles course load into the ES storage MongoDB and Elasticsearch targets
-// It takes the course objects alog with the target storage parameters, calls convertToESCourse to generate storage-ready objects
-// from the course object and manages the load
-func upsertCourseRecursive(course *Course, mongoURI, dbName string, elasticsearchURI string, elasticsearchIndex string) (err error) {
+// upsertCourseRecursive handles course load into the ES storage MongoDB and Elasticsearch targets, or any other courseStore
+// It takes the course objects alog with the target store, calls convertToESCourse to generate storage-ready objects
+// from the course object and manages the load. In incremental mode only the records whose content changed since the last
+// push, or that are missing from the store, are written. Records of the course that are no longer produced are reported,
+// and removed if pruning is enabled
+func upsertCourseRecursive(course *Course, store courseStore, elasticsearchIndex string, opts pushOptions) (err error) {
 
-	sess, err := mgo.DialWithTimeout(mongoURI, time.Duration(10*time.Second))
+	esc, exams, qs, vcs, esearchdocs, err := convertToESCourse(course)
 	if err != nil {
-		Log.Error("MongoDB error", err)
 		return err
 	}
 
-	esc, exams, qs, vcs, esearchdocs, err := convertToESCourse(course)
+	plan, err := newPushPlan(store, esc.ID, opts.Incremental)
+	if err != nil {
+		Log.Errorf("Error reading the push state of course %s: %s", esc.ID, err.Error())
+		return err
+	}
+	storedRecords, err := findStoredCourseRecords(store, course, elasticsearchIndex, plan)
 	if err != nil {
+		Log.Errorf("Error listing the stored records of course %s: %s", esc.ID, err.Error())
 		return err
 	}
-	db := sess.DB(dbName)
+	recordIDs := map[string][]string{pushKindCourse: {esc.ID}}
+	for _, q := range qs {
+		recordIDs[pushKindQuestion] = append(recordIDs[pushKindQuestion], q.ID)
+	}
+	for _, vc := range vcs {
+		recordIDs[pushKindVersionedContent] = append(recordIDs[pushKindVersionedContent], vc.ID)
+	}
+	for _, ex := range exams {
+		recordIDs[pushKindExam] = append(recordIDs[pushKindExam], ex.ID)
+	}
+	for _, kind := range []string{pushKindCourse, pushKindExam, pushKindQuestion, pushKindVersionedContent} {
+		err = plan.lookUpStoredRecords(store, kind, recordIDs[kind])
+		if err != nil {
+			return err
+		}
+	}
 
 	for _, q := range qs {
-		// cInfo, err := db.C("question").UpsertId(q.ID, q)
-		_, err := db.C("question").UpsertId(q.ID, q)
+		if write, err := plan.needsWrite(pushKindQuestion, q.ID, q); err != nil || !write {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := keepCreatedAt(store, pushKindQuestion, q.ID, q)
+		if err != nil {
+			return err
+		}
+		err = store.UpsertRecord(pushKindQuestion, q.ID, q)
 		if err != nil {
-			Log.Errorf("MongoDB error with 'question' object: %v, and error: %s", q, err.Error())
 			return err
 		}
-		// Log.Info("EXLskills 'question' changes: ", *cInfo)
 	}
 
 	for _, vc := range vcs {
-		//cInfo, err := db.C("versioned_content").UpsertId(vc.ID, vc)
-		_, err := db.C("versioned_content").UpsertId(vc.ID, vc)
+		if write, err := plan.needsWrite(pushKindVersionedContent, vc.ID, vc); err != nil || !write {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		stored := &esmodels.VersionedContent{}
+		found, err := store.FindRecord(pushKindVersionedContent, vc.ID, stored)
+		if err != nil {
+			return err
+		}
+		if !found {
+			stored = nil
+		}
+		changed, err := mergeContentHistory(vc, stored, opts.ContentVersionsKept)
+		if err != nil || !changed {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = store.UpsertRecord(pushKindVersionedContent, vc.ID, vc)
 		if err != nil {
-			Log.Errorf("MongoDB error with 'versioned_content' object: %v, and error: %s", vc, err.Error())
 			return err
 		}
-		// Log.Info("EXLskills 'versioned_content' changes: ", *cInfo)
 	}
 
 	for _, ex := range exams {
-		cInfo, err := db.C("exam").UpsertId(ex.ID, ex)
+		if write, err := plan.needsWrite(pushKindExam, ex.ID, ex); err != nil || !write {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := keepCreatedAt(store, pushKindExam, ex.ID, ex)
+		if err != nil {
+			return err
+		}
+		err = store.UpsertRecord(pushKindExam, ex.ID, ex)
 		if err != nil {
-			Log.Errorf("MongoDB error with 'exam' object: %v, and error: %s", ex, err.Error())
 			return err
 		}
-		Log.Info("EXLskills 'exam' changes: ", *cInfo)
+		Log.Infof("EXLskills 'exam' %s written", ex.ID)
 	}
 
 	Log.Debugf("Course Timestamp: %s", esc.ContentUpdatedAt)
-	// Note: this records the time the record was inserted or potentially updated in the target DB
-	esc.StaticDataUpdatedAt = time.Now()
-	// Record-level field
-	esc.UpdatedAt = time.Now()
-
-	cInfo, err := db.C("course").UpsertId(esc.ID, esc)
+	writeCourse, err := plan.needsWrite(pushKindCourse, esc.ID, esc)
 	if err != nil {
-		Log.Errorf("MongoDB error with 'course' object: %v, and error: %s", esc, err.Error())
 		return err
 	}
-	Log.Info("EXLskills 'course' changes: ", *cInfo)
-
-	if len(elasticsearchURI) > 0 {
-		u, err := url.Parse(elasticsearchURI)
+	if writeCourse {
+		err = keepCreatedAt(store, pushKindCourse, esc.ID, esc)
 		if err != nil {
-			Log.Errorf("Elasticsearch URI is invalid: %v. Parsing error: %s", elasticsearchURI, err.Error())
 			return err
 		}
 
-		var elasticSearchClient *elastic.Client
-
-		if u.Scheme == "https" && !config.Cfg().IsProductionMode() {
-			// This is used for testing HTTPS backends bypassing Certificate validation
-			// Set ENV MODE=debug
-			tr := &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			}
-			client := &http.Client{Transport: tr}
-			elasticSearchClient, err = elastic.NewClient(elastic.SetHttpClient(client), elastic.SetSniff(false), elastic.SetURL(elasticsearchURI))
-		} else {
-			// This is used in Production and for HTTP backend testing
-			elasticSearchClient, err = elastic.NewClient(elastic.SetSniff(false), elastic.SetURL(elasticsearchURI))
-		}
+		err = store.UpsertRecord(pushKindCourse, esc.ID, esc)
 		if err != nil {
-			Log.Errorf("Elasticsearch connection issue for URI: %v. Error: %s", elasticsearchURI, err.Error())
 			return err
 		}
-		Log.Info("Elasticsearch connected ", elasticSearchClient)
-
-		Log.Infof("Starting to load Elasticsearch documents. There are %v documents to load", len(esearchdocs))
-		Log.Infof("Target Index %v", elasticsearchIndex+"_"+course.GetLanguage())
-		elasticsearchDocs := 0
-		for _, esd := range esearchdocs {
-			elasticsearchDocs++
-			Log.Debugf("Loading doc ID %v type %v title %v", esd.ID, esd.DocType, esd.Title)
-			_, err = elasticSearchClient.Index().
-				Index(elasticsearchIndex + "_" + course.GetLanguage()).
-				Type("_doc").
-				Id(esd.ID).
-				BodyJson(esd).
-				Refresh("false").
-				Do(context.Background())
+		Log.Infof("EXLskills 'course' %s written", esc.ID)
+	}
+
+	if store.HasSearchIndex() {
+		// Each language has its own index, the course language comes first
+		for _, locale := range append([]string{course.GetLanguage()}, course.TranslationLocales...) {
+			kind := searchDocKind(course, locale)
+			esAlias := elasticsearchIndex + "_" + locale
+			err = store.PrepareSearchIndex(esAlias, locale, opts.ReindexSearch)
+			if err != nil {
+				return err
+			}
+			err = plan.lookUpStoredSearchDocs(store, kind, esAlias)
 			if err != nil {
-				// Handle error
-				Log.Errorf("Elasticsearch index issue for URI: %v, and error: %s", elasticsearchURI, err.Error())
 				return err
 			}
 
-		}
-		Log.Infof("Elasticsearch: indexed %v documents", elasticsearchDocs)
-	}
-
-	return
-}
-
-func checkWalkErrors(c Course) error {
-	var errorText strings.Builder
-	for _, chapter := range c.Chapters {
-		for _, sequential := range chapter.Sequentials {
-			for _, vertical := range sequential.Verticals {
-				for _, block := range vertical.Blocks {
-					if block.BlockType == "ERROR" {
-						errorText.WriteString(fmt.Sprintf("Error processing blocks for vertical %s (ID: %s), error: %s ", vertical.DisplayName, vertical.URLName, block.Markdown))
+			toIndex := make([]*esmodels.ElasticsearchGenDoc, 0, len(esearchdocs))
+			for _, esd := range esearchdocs {
+				if searchDocLocale(course, esd) != locale {
+					continue
+				}
+				if write, err := plan.needsWrite(kind, esd.ID, esd); err != nil || !write {
+					if err != nil {
+						return err
 					}
+					continue
 				}
+				toIndex = append(toIndex, esd)
+			}
+			Log.Infof("Starting to load Elasticsearch documents. There are %v documents to load", len(toIndex))
+			Log.Infof("Target Index %v", esAlias)
+			err = store.IndexSearchDocs(esAlias, toIndex)
+			if err != nil {
+				Log.Errorf("Elasticsearch index issue for index: %v, and error: %s", esAlias, err.Error())
+				return err
 			}
 		}
 	}
-	errorS := errorText.String()
-	if len(errorS) > 0 {
-		return errors.New(errorS)
+
+	plan.logSummary()
+	err = pruneOrphanedRecords(store, course, elasticsearchIndex, plan, storedRecords, opts.Prune)
+	if err != nil {
+		return err
 	}
-	return nil
+	err = plan.save(store)
+	if err != nil {
+		Log.Errorf("Error saving the push state of course %s: %s", esc.ID, err.Error())
+		return err
+	}
+	return
 }
 
 // convertToESCourse takes the Course object as populated in preceding steps and generates objects corresponding to the ES course storage model:
 // Four objects for the MongoDB collections and one object for the Elasticsearch index
+// The texts of each translation of the course are added to the records as non-default strings of its locale, and the
+// search docs of the translation are returned along with the others, marked with the locale
 func convertToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.Exam, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
+	esc, exams, qs, vc, esearchdocs, err = convertLanguageToESCourse(course)
+	if err != nil {
+		return
+	}
+	for _, locale := range course.TranslationLocales {
+		lc, err := localizedCourse(course, locale)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		lEsc, lExams, lQs, lVc, lEsearchdocs, err := convertLanguageToESCourse(lc)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		for _, pair := range []struct {
+			path     string
+			dst, src interface{}
+		}{
+			{"course " + esc.ID, esc, lEsc},
+			{"exams of course " + esc.ID, exams, lExams},
+			{"questions of course " + esc.ID, qs, lQs},
+			{"card contents of course " + esc.ID, vc, lVc},
+		} {
+			err = addRecordTranslations(pair.dst, pair.src, locale, pair.path)
+			if err != nil {
+				return nil, nil, nil, nil, nil, err
+			}
+		}
+		for _, esd := range lEsearchdocs {
+			esd.Locale = locale
+		}
+		esearchdocs = append(esearchdocs, lEsearchdocs...)
+	}
+	return
+}
+
+// convertLanguageToESCourse converts the course in its own language
+func convertLanguageToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.Exam, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
 	estMinutes, err := strconv.Atoi(course.GetExtraAttributes()["est_minutes"])
 	if err != nil {
 		// Note this is just a sensible default, I don't believe that est_minutes should crash a course conversion
@@ -484,13 +675,17 @@ func convertToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.
 	if err != nil {
 		return nil, nil, nil, nil, nil, err
 	}
+	organizationIDs := course.OrganizationIDs
+	if organizationIDs == nil {
+		organizationIDs = []string{}
+	}
 	esc = &esmodels.Course{
 		ID:                 course.URLName,
-		IsOrganizationOnly: false,
+		IsOrganizationOnly: course.IsOrganizationOnly,
 		Title:              esmodels.NewIntlStringWrapper(course.DisplayName, course.Language),
 		Description:        esmodels.NewIntlStringWrapper(course.GetExtraAttributes()["description"], course.Language),
 		Headline:           esmodels.NewIntlStringWrapper(course.GetExtraAttributes()["headline"], course.Language),
-		SubscriptionLevel:  1,
+		SubscriptionLevel:  course.GetSubscriptionLevel(),
 		ViewCount:          0,
 		EnrolledCount:      0,
 		SkillLevel:         skillLevel,
@@ -498,14 +693,18 @@ func convertToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.
 		PrimaryTopic:       course.GetExtraAttributes()["primary_topic"],
 		CoverURL:           course.GetCourseImage(),
 		LogoURL:            course.GetCourseImage(),
-		IsPublished:        true,
+		IsPublished:        course.GetIsPublished(),
 		InfoMD:             esmodels.NewIntlStringWrapper(course.GetExtraAttributes()["info_md"], course.Language),
-		VerifiedCertCost:   30,
-		OrganizationIDs:    []string{},
+		VerifiedCertCost:   course.GetVerifiedCertCost(),
+		OrganizationIDs:    organizationIDs,
 		Topics:             extraAttrCSVToStrSlice(course.GetExtraAttributes()["topics"]),
 		RepoURL:            course.GetExtraAttributes()["repo_url"],
 		Weight:             weight,
 		ContentUpdatedAt:   course.ContentUpdatedAt,
+		// The settings are read along with the content, so they were last changed with it
+		StaticDataUpdatedAt: course.ContentUpdatedAt,
+		UpdatedAt:           course.ContentUpdatedAt,
+		CreatedAt:           course.ContentCreatedAt,
 	}
 	if course.GetExtraAttributes()["instructor_timekit"] != "" {
 		instTK := esmodels.InstructorTimekit{}
@@ -520,9 +719,10 @@ func convertToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.
 		return
 	}
 	esc.Units = esmodels.UnitsWrapper{
-		ID:    esmodels.ESID(),
+		ID:    esmodels.StableESID(course.URLName, "units"),
 		Units: units,
 	}
+	stampTimes(esc, course.ContentCreatedAt, course.ContentUpdatedAt)
 
 	esearchdoc := &esmodels.ElasticsearchGenDoc{
 		ID:          toGlobalId("Course", course.URLName),
@@ -537,8 +737,12 @@ func convertToESCourse(course *Course) (esc *esmodels.Course, exams []*esmodels.
 }
 
 func extractESFeatures(course *Course) (units []esmodels.Unit, exams []*esmodels.Exam, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
-	for _, chap := range course.Chapters {
-		unit, uEx, uQs, uVcs, uEsearchdocs, err := extractESUnitFeatures(course.URLName, course.RepoURL, chap, len(course.Chapters), course.Language)
+	weights, err := finalExamWeights(course)
+	if err != nil {
+		return
+	}
+	for idx, chap := range course.Chapters {
+		unit, uEx, uQs, uVcs, uEsearchdocs, err := extractESUnitFeatures(course.URLName, course.RepoURL, chap, weights[idx], course.Language)
 		if err != nil {
 			return nil, nil, nil, nil, nil, err
 		}
@@ -548,20 +752,26 @@ func extractESFeatures(course *Course) (units []esmodels.Unit, exams []*esmodels
 		vc = append(vc, uVcs...)
 		esearchdocs = append(esearchdocs, uEsearchdocs...)
 	}
+	err = completeExams(course, exams, qs)
 	return
 }
 
-func extractESUnitFeatures(courseID string, courseRepoUrl string, chap *Chapter, nChaps int, lang string) (unit esmodels.Unit, exams []*esmodels.Exam, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
+func extractESUnitFeatures(courseID string, courseRepoUrl string, chap *Chapter, examWeightPct float64, lang string) (unit esmodels.Unit, exams []*esmodels.Exam, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
 	Log.Debug("Extracting ESUnit Features for ", chap.DisplayName)
 	unit.ID = chap.URLName
 	unit.Title = esmodels.NewIntlStringWrapper(chap.DisplayName, lang)
-	unit.Headline = esmodels.NewIntlStringWrapper("Learn "+chap.DisplayName, lang)
+	unit.Headline = esmodels.NewIntlStringWrapper(chap.headlineOr(chap.DisplayName), lang)
+	unit.Description = esmodels.NewIntlStringWrapper(chap.Description, lang)
+	unit.Tags = chap.tagsOrEmpty()
 	unit.Index = chap.Index + 1
-	unit.FinalExamWeightPct = (1 / float64(nChaps)) * 100
-	unit.AttemptsAllowedPerDay = 2
+	unit.FinalExamWeightPct = examWeightPct
+	unit.AttemptsAllowedPerDay, _, err = chapterExamSettings(chap)
+	if err != nil {
+		return
+	}
 	sections := make([]esmodels.Section, 0, len(chap.Sequentials))
 	for idx, seq := range chap.Sequentials {
-		if seq.GetIsGraded() && strings.HasPrefix(seq.Format, "Final Exam") {
+		if seq.isFinalExam() {
 			seqEx, seqQs, err := extractESExamFeatures(courseID, chap.URLName, seq, lang)
 			if err != nil {
 				return esmodels.Unit{}, nil, nil, nil, nil, err
@@ -583,23 +793,35 @@ func extractESUnitFeatures(courseID string, courseRepoUrl string, chap *Chapter,
 	unit.Sections = esmodels.SectionsWrapper{
 		Sections: sections,
 	}
+	unit.EstMinutes = chap.EstMinutes
+	if unit.EstMinutes == 0 {
+		for _, sect := range sections {
+			unit.EstMinutes += sect.EstMinutes
+		}
+	}
 
 	unit.UpdatedAt = chap.UpdatedAt
+	unit.CreatedAt = chap.CreatedAt
+	stampTimes(&unit, chap.CreatedAt, chap.UpdatedAt)
 
 	esearchdoc := &esmodels.ElasticsearchGenDoc{
-		ID:       toGlobalId("Unit", unit.ID),
-		DocType:  "unit",
-		Title:    chap.DisplayName,
-		Headline: "Learn " + chap.DisplayName,
-		CourseId: courseID,
-		UnitId:   unit.ID,
+		ID:          toGlobalId("Unit", unit.ID),
+		DocType:     "unit",
+		Title:       chap.DisplayName,
+		Headline:    chap.headlineOr(chap.DisplayName),
+		TextContent: chap.Description,
+		CourseId:    courseID,
+		UnitId:      unit.ID,
+		Tags:        chap.Tags,
 	}
 	esearchdocs = append(esearchdocs, esearchdoc)
 
 	return
 }
 
-func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *Block, rpl *BlockREPL, lang string) (*esmodels.Question, error) {
+// extractEQQuestionFromBlock converts the problem block into a question, which takes its timestamps from the vertical of
+// the block
+func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *Block, rpl *BlockREPL, lang string, createdAt, updatedAt time.Time) (*esmodels.Question, error) {
 	var qData interface{}
 	var qType string
 	var qLabel esmodels.IntlStringWrapper
@@ -608,20 +830,30 @@ func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *B
 	if err != nil {
 		return nil, err
 	}
+	if rpl != nil && rpl.Explanation == "" && qBlk.Meta != nil && qBlk.Meta.Explanation != "" {
+		withExpl := *rpl
+		withExpl.Explanation = qBlk.Meta.Explanation
+		rpl = &withExpl
+	}
 	// Log.Info(probMD)
 	olxProblem, err := olxproblems.NewProblemFromMD(probMD)
 	if err != nil {
 		return nil, err
 	}
 	var qHint esmodels.IntlStringWrapper
-	if olxProblem.DemandHint != nil {
-		qHint = esmodels.NewIntlStringWrapper(olxProblem.DemandHint.Hint, lang)
+	qHints := []esmodels.IntlStringWrapper{}
+	if olxProblem.DemandHint != nil && len(olxProblem.DemandHint.Hints) > 0 {
+		for _, h := range olxProblem.DemandHint.Hints {
+			qHints = append(qHints, esmodels.NewIntlStringWrapper(h, lang))
+		}
+		// The single hint stays the last, most revealing one
+		qHint = qHints[len(qHints)-1]
 	}
 	if olxProblem.MultipleChoiceResponse != nil {
 		qEstSecs = 60
 		qType = esmodels.ESTypeFromOLXType("multiplechoiceresponse")
 		qLabel = esmodels.NewIntlStringWrapper(olxProblem.MultipleChoiceResponse.Label.InnerXML, lang)
-		qData, err = olxChoicesToESQDataArr(olxProblem.MultipleChoiceResponse.ChoiceGroup.Choices, lang)
+		qData, err = olxChoicesToESQDataArr(quesID, olxProblem.MultipleChoiceResponse.ChoiceGroup.Choices, lang, createdAt, updatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -629,7 +861,7 @@ func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *B
 		qEstSecs = 60
 		qType = esmodels.ESTypeFromOLXType("choiceresponse")
 		qLabel = esmodels.NewIntlStringWrapper(olxProblem.ChoiceResponse.Label.InnerXML, lang)
-		qData, err = olxChoicesToESQDataArr(olxProblem.ChoiceResponse.CheckboxGroup.Choices, lang)
+		qData, err = olxChoicesToESQDataArr(quesID, olxProblem.ChoiceResponse.CheckboxGroup.Choices, lang, createdAt, updatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -637,10 +869,20 @@ func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *B
 		qEstSecs = 60 * 5
 		qType = esmodels.ESTypeFromOLXType("stringresponse")
 		qLabel = esmodels.NewIntlStringWrapper(olxProblem.StringResponse.Label.InnerXML, lang)
-		qData, err = olxStrRespToESQCodeData(lang, olxProblem.StringResponse.Answer, rpl)
+		qData, err = olxStrRespToESQCodeData(quesID, lang, olxProblem.StringResponse.Answer, rpl)
 		if err != nil {
 			return nil, err
 		}
+	} else if olxProblem.OptionResponse != nil {
+		qEstSecs = 60
+		qType = esmodels.ESTypeFromOLXType("optionresponse")
+		qLabel = esmodels.NewIntlStringWrapper(olxProblem.OptionResponse.Label.InnerXML, lang)
+		qData = olxOptionsToESQDataArr(quesID, olxProblem.OptionResponse.OptionInput.Options, lang, createdAt, updatedAt)
+	} else if olxProblem.NumericalResponse != nil {
+		qEstSecs = 60 * 2
+		qType = esmodels.ESTypeFromOLXType("numericalresponse")
+		qLabel = esmodels.NewIntlStringWrapper(olxProblem.NumericalResponse.Label.InnerXML, lang)
+		qData = olxNumRespToESQNumData(quesID, olxProblem.NumericalResponse, lang)
 	} else {
 		return nil, errors.New(fmt.Sprintf("invalid olx problem type: %s", olxProblem.XMLName.Local))
 	}
@@ -651,6 +893,7 @@ func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *B
 		QuestionText: qLabel,
 		EstTimeSec:   qEstSecs,
 		Hint:         qHint,
+		Hints:        qHints,
 		DocRef: esmodels.DocRef{
 			EmbeddedDocRef: esmodels.EmbeddedDocRefWrapper{
 				EmbeddedDocRefs: []esmodels.EmbeddedDocRef{
@@ -674,52 +917,66 @@ func extractEQQuestionFromBlock(courseID, unitID, sectID, quesID string, qBlk *B
 			UnitID:    unitID,
 			SectionID: sectID,
 		},
-		// todo tags
 		Tags:            []string{},
 		Points:          1,
 		ComplexityLevel: 1,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		CreatedAt:       createdAt,
+		UpdatedAt:       updatedAt,
+	}
+	if qBlk.Meta != nil {
+		qBlk.Meta.applyTo(q, lang)
 	}
+	stampTimes(q, createdAt, updatedAt)
 	return q, nil
 }
 
 func extractESExamFeatures(courseID, unitID string, sequential *Sequential, lang string) (exam *esmodels.Exam, qs []*esmodels.Question, err error) {
+	examCfg := sequential.examConfig()
 	exam = &esmodels.Exam{}
-	exam.UseIDETestMode = true
+	exam.UseIDETestMode = examCfg.getUseIDETestMode()
 	exam.ID = sequential.URLName + "_exam"
-	// 1ejFaqz00nJy is Sasha Varlamov
-	exam.CreatorID = "1ejFaqz00nJy"
-	// TODO pull PassMarkPct from sequential
-	exam.PassMarkPct = 75
+	exam.CreatorID = examCfg.getCreatorID()
+	exam.PassMarkPct = examCfg.getPassMarkPct()
+	exam.RandomOrder = examCfg.RandomOrder
+	exam.Tags = examCfg.Tags
+	exam.CreatedAt = sequential.CreatedAt
+	// Each vertical is a slot of the exam, a question is drawn out of its problems if it has more than one. A vertical
+	// whose problems all have the tag of a pool is not a slot, its problems are left for the pools drawn by tag. The
+	// slots are all pools until completeExams moves those without a choice to the fixed questions
 	for _, vert := range sequential.Verticals {
-		var qBlk *Block
-		if len(vert.Blocks) != 1 {
-			return nil, nil, errors.New(fmt.Sprintf("final exam vertical should have exactly one block (a problem block) (Vertical ID: %s)", vert.URLName))
+		if len(vert.Blocks) == 0 {
+			return nil, nil, errors.New(fmt.Sprintf("final exam vertical should have at least one block (a problem block) (Vertical ID: %s)", vert.URLName))
 		}
-		for _, b := range vert.Blocks {
-			if b.BlockType == "problem" {
-				qBlk = b
-			} else {
+		slot := esmodels.ExamPool{Draw: 1}
+		var slotQs []*esmodels.Question
+		for bIdx, qBlk := range vert.Blocks {
+			if qBlk.BlockType != "problem" {
 				return nil, nil, errors.New("final exam vertical block must be of type 'problem'")
 			}
+			quesID := vert.URLName
+			if bIdx > 0 {
+				quesID = fmt.Sprintf("%s_%d", vert.URLName, bIdx)
+			}
+			q, err := extractEQQuestionFromBlock(courseID, unitID, sequential.URLName, quesID, qBlk, qBlk.REPL, lang, vert.CreatedAt, vert.UpdatedAt)
+			if err != nil {
+				return nil, nil, err
+			}
+			q.ExamOnly = true
+			slot.QuestionIDs = append(slot.QuestionIDs, q.ID)
+			slotQs = append(slotQs, q)
+			qs = append(qs, q)
 		}
-		q, err := extractEQQuestionFromBlock(courseID, unitID, sequential.URLName, vert.URLName, qBlk, qBlk.REPL, lang)
-		if err != nil {
-			return nil, nil, err
+		if examCfg.isPoolMaterial(slotQs) {
+			continue
 		}
-		q.ExamOnly = true
-		exam.EstTime += int(math.Round(float64(q.EstTimeSec) / 60))
-		exam.TimeLimit += int(math.Round((float64(q.EstTimeSec) * 1.5) / 60))
-		exam.QuestionIDs = append(exam.QuestionIDs, q.ID)
-		exam.QuestionCount++
-		qs = append(qs, q)
+		addExamPool(exam, slot, slotQs)
 	}
+	stampTimes(exam, sequential.CreatedAt, sequential.UpdatedAt)
 	return exam, qs, nil
 }
 
 // olxStrRespToESQCodeData ans field represents the shebang (#!) that points us to the REPL configuration
-func olxStrRespToESQCodeData(lang, ans string, rpl *BlockREPL) (cqd esmodels.CodeQuestionData, err error) {
+func olxStrRespToESQCodeData(quesID, lang, ans string, rpl *BlockREPL) (cqd esmodels.CodeQuestionData, err error) {
 	if !isValidProblemREPLShebang(ans) {
 		Log.Errorf("Invalid problem shebang. Got %s for repl %v", ans, *rpl)
 		return cqd, errors.New("stringresponse problem invalid answer shebang (#!)")
@@ -744,7 +1001,7 @@ func olxStrRespToESQCodeData(lang, ans string, rpl *BlockREPL) (cqd esmodels.Cod
 	if rpl.GradingStrategy != "" {
 		strategy = rpl.GradingStrategy
 	}
-	explWspcBytes, err := json.Marshal(wsenv.Workspace{Id: esmodels.ESID(), EnvironmentKey: rpl.EnvironmentKey, Files: rpl.SrcFiles})
+	explWspcBytes, err := json.Marshal(wsenv.Workspace{Id: esmodels.StableESID(quesID, "explanation_workspace"), EnvironmentKey: rpl.EnvironmentKey, Files: rpl.SrcFiles})
 	if err != nil {
 		return cqd, err
 	}
@@ -753,7 +1010,7 @@ func olxStrRespToESQCodeData(lang, ans string, rpl *BlockREPL) (cqd esmodels.Cod
 		return cqd, err
 	}
 	return esmodels.CodeQuestionData{
-		ID:              bson.NewObjectId(),
+		ID:              esmodels.StableObjectID(quesID, "data"),
 		APIVersion:      rpl.APIVersion,
 		EnvironmentKey:  rpl.EnvironmentKey,
 		SrcFiles:        esmodels.NewIntlStringWrapper(string(srcFilesJson), lang),
@@ -780,39 +1037,77 @@ func generateCodeQuestionExplanationMD(explStr, srcWorkspace string) (string, er
 	return buf.String(), nil
 }
 
-func olxStripHintsFromMD(md string) string {
-	return olxProblemChoiceHintsMdRegex.ReplaceAllString(md, "")
-}
-
-func olxChoicesToESQDataArr(choices []olxproblems.Choice, lang string) ([]esmodels.AnswerChoice, error) {
+func olxChoicesToESQDataArr(quesID string, choices []olxproblems.Choice, lang string, createdAt, updatedAt time.Time) ([]esmodels.AnswerChoice, error) {
 	esc := make([]esmodels.AnswerChoice, 0, len(choices))
 	for ind, c := range choices {
-		txtMd, err := mdutils.MakeMD(olxStripHintsFromMD(c.InnerXML), "github")
-		if err != nil {
-			return nil, err
-		}
-		hintMd := ""
-		if c.ChoiceHint != nil && len(c.ChoiceHint) > 0 {
-			// TODO see how to handle multiple hints, since exlskills is only capable of one hint ("explanation")
-			hintMd, err = mdutils.MakeMD(c.ChoiceHint[0].InnerXML, "github")
-			if err != nil {
-				return nil, err
+		// The native problem parser leaves the choice text and hints as markdown, so they can be stored as-is
+		txtMd := c.InnerXML
+		// A choice hint without the selected attribute is shown when the choice is selected
+		var selectedMd, unselectedMd []string
+		for _, h := range c.ChoiceHint {
+			if h.Selected != nil && !*h.Selected {
+				unselectedMd = append(unselectedMd, h.InnerXML)
+			} else {
+				selectedMd = append(selectedMd, h.InnerXML)
 			}
 		}
 		esc = append(esc, esmodels.AnswerChoice{
-			ID: bson.NewObjectId(),
+			ID: esmodels.StableObjectID(quesID, "choice", strconv.Itoa(ind)),
 			// NOTE: This magic math comes from the course collection's schema where the seq is always (index+1)*10
-			Sequence:    (ind + 1) * 10,
-			Text:        esmodels.NewIntlStringWrapper(txtMd, lang),
-			IsAnswer:    c.Correct,
-			Explanation: esmodels.NewIntlStringWrapper(hintMd, lang),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			Sequence:              (ind + 1) * 10,
+			Text:                  esmodels.NewIntlStringWrapper(txtMd, lang),
+			IsAnswer:              c.Correct,
+			Explanation:           esmodels.NewIntlStringWrapper(strings.Join(selectedMd, "\n\n"), lang),
+			UnselectedExplanation: esmodels.NewIntlStringWrapper(strings.Join(unselectedMd, "\n\n"), lang),
+			CreatedAt:             createdAt,
+			UpdatedAt:             updatedAt,
 		})
 	}
 	return esc, nil
 }
 
+func olxOptionsToESQDataArr(quesID string, options []olxproblems.Option, lang string, createdAt, updatedAt time.Time) []esmodels.AnswerChoice {
+	esc := make([]esmodels.AnswerChoice, 0, len(options))
+	for ind, o := range options {
+		// All the hints of an option are shown when it is selected, as for the choices
+		hintMd := make([]string, 0, len(o.OptionHint))
+		for _, h := range o.OptionHint {
+			hintMd = append(hintMd, h.InnerXML)
+		}
+		esc = append(esc, esmodels.AnswerChoice{
+			ID: esmodels.StableObjectID(quesID, "choice", strconv.Itoa(ind)),
+			// NOTE: Same sequence math as olxChoicesToESQDataArr
+			Sequence:    (ind + 1) * 10,
+			Text:        esmodels.NewIntlStringWrapper(o.InnerXML, lang),
+			IsAnswer:    o.Correct,
+			Explanation: esmodels.NewIntlStringWrapper(strings.Join(hintMd, "\n\n"), lang),
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
+		})
+	}
+	return esc
+}
+
+func olxNumRespToESQNumData(quesID string, nr *olxproblems.NumericalResponse, lang string) esmodels.NumericalQuestionData {
+	nqd := esmodels.NumericalQuestionData{
+		ID:                esmodels.StableObjectID(quesID, "data"),
+		Answer:            nr.Answer,
+		AdditionalAnswers: make([]string, 0, len(nr.AdditionalAnswers)),
+	}
+	if nr.ResponseParam != nil && nr.ResponseParam.Type == "tolerance" {
+		nqd.Tolerance = nr.ResponseParam.Default
+	}
+	for _, aa := range nr.AdditionalAnswers {
+		nqd.AdditionalAnswers = append(nqd.AdditionalAnswers, aa.Answer)
+	}
+	hintMd := ""
+	if nr.CorrectHint != nil {
+		hintMd = nr.CorrectHint.InnerXML
+	}
+	nqd.Explanation = esmodels.NewIntlStringWrapper(hintMd, lang)
+	return nqd
+}
+
 // extractESSectionFeatures iterates over sequential.Verticals that represents the lowest level in the topic structure hierarchy
 // Each element in sequential.Verticals contains one set of vert.Blocks comprising one Card
 func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int, sequential *Sequential, lang string) (section esmodels.Section, qs []*esmodels.Question, vc []*esmodels.VersionedContent, esearchdocs []*esmodels.ElasticsearchGenDoc, err error) {
@@ -820,7 +1115,9 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 	section.ID = sequential.URLName
 	section.Index = index + 1
 	section.Title = esmodels.NewIntlStringWrapper(sequential.DisplayName, lang)
-	section.Headline = esmodels.NewIntlStringWrapper("Learn "+sequential.DisplayName, lang)
+	section.Headline = esmodels.NewIntlStringWrapper(sequential.headlineOr(sequential.DisplayName), lang)
+	section.Description = esmodels.NewIntlStringWrapper(sequential.Description, lang)
+	section.Tags = sequential.tagsOrEmpty()
 	for idx, vert := range sequential.Verticals {
 		var contentBuf bytes.Buffer
 		var ghEditUrl string
@@ -838,7 +1135,7 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 				)
 				if blk.REPL.SrcFiles != nil {
 					b, err := json.Marshal(wsenv.Workspace{
-						Id:             esmodels.ESID(),
+						Id:             esmodels.StableESID(blk.URLName, "src_workspace"),
 						Name:           blk.DisplayName,
 						EnvironmentKey: blk.REPL.EnvironmentKey,
 						Files:          blk.REPL.SrcFiles,
@@ -851,7 +1148,7 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 				}
 				if blk.REPL.TmplFiles != nil {
 					b, err := json.Marshal(wsenv.Workspace{
-						Id:             esmodels.ESID(),
+						Id:             esmodels.StableESID(blk.URLName, "tmpl_workspace"),
 						Name:           blk.DisplayName,
 						EnvironmentKey: blk.REPL.EnvironmentKey,
 						Files:          blk.REPL.TmplFiles,
@@ -863,7 +1160,7 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 				}
 				if blk.REPL.TestFiles != nil {
 					b, err := json.Marshal(wsenv.Workspace{
-						Id:             esmodels.ESID(),
+						Id:             esmodels.StableESID(blk.URLName, "test_workspace"),
 						Name:           blk.DisplayName,
 						EnvironmentKey: blk.REPL.EnvironmentKey,
 						Files:          blk.REPL.TestFiles,
@@ -912,13 +1209,14 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 		}
 		qids := make([]string, 0, len(qBlks))
 		for qIdx, q := range qBlks {
-			ques, err := extractEQQuestionFromBlock(courseID, unitID, section.ID, fmt.Sprintf("%s_q_%d", vert.URLName, qIdx), q, q.REPL, lang)
+			ques, err := extractEQQuestionFromBlock(courseID, unitID, section.ID, fmt.Sprintf("%s_q_%d", vert.URLName, qIdx), q, q.REPL, lang, vert.CreatedAt, vert.UpdatedAt)
 			if err != nil {
 				Log.Error(err)
 				return section, nil, nil, nil, err
 			}
 			ques.DocRef.EmbeddedDocRef.EmbeddedDocRefs = append(ques.DocRef.EmbeddedDocRef.EmbeddedDocRefs, esmodels.EmbeddedDocRef{DocID: vert.URLName, Level: "card"})
 			ques.CourseItemRef.CardID = vert.URLName
+			stampTimes(ques, vert.CreatedAt, vert.UpdatedAt)
 			qids = append(qids, ques.ID)
 			qs = append(qs, ques)
 		}
@@ -927,17 +1225,20 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 			LatestVersion: 1,
 			Contents: []esmodels.Content{
 				{
-					ID:      bson.NewObjectId(),
+					ID:      esmodels.StableObjectID(vert.URLName+"_vc", "1"),
 					Version: 1,
 					Content: esmodels.NewIntlStringWrapper(contentBuf.String(), lang),
 				},
 			},
 		}
+		stampTimes(verContent, vert.CreatedAt, vert.UpdatedAt)
 		vc = append(vc, verContent)
 		card := esmodels.Card{
 			ID:          vert.URLName,
 			Title:       esmodels.NewIntlStringWrapper(vert.DisplayName, lang),
-			Headline:    esmodels.NewIntlStringWrapper("Learn "+vert.DisplayName, lang),
+			Headline:    esmodels.NewIntlStringWrapper(vert.headlineOr(vert.DisplayName), lang),
+			Description: esmodels.NewIntlStringWrapper(vert.Description, lang),
+			EstMinutes:  vert.EstMinutes,
 			Index:       idx + 1,
 			ContentID:   vert.URLName + "_vc",
 			QuestionIDs: qids,
@@ -948,38 +1249,54 @@ func extractESSectionFeatures(courseID, courseRepoUrl, unitID string, index int,
 				CardID:    vert.URLName,
 			},
 			GithubEditURL: ghEditUrl,
-			// TODO tags
-			Tags:      []string{},
-			UpdatedAt: vert.UpdatedAt,
+			Tags:          vert.tagsOrEmpty(),
+			UpdatedAt:     vert.UpdatedAt,
+			CreatedAt:     vert.CreatedAt,
 		}
+		stampTimes(&card, vert.CreatedAt, vert.UpdatedAt)
 		section.Cards.Cards = append(section.Cards.Cards, card)
 		Log.Debug("Added Card ", vert.DisplayName)
 
 		section.UpdatedAt = sequential.UpdatedAt
+		section.CreatedAt = sequential.CreatedAt
 
+		cardTextContent := cardText.String()
+		if vert.Description != "" {
+			cardTextContent = vert.Description + "\n\n" + cardTextContent
+		}
 		esearchdoc := &esmodels.ElasticsearchGenDoc{
 			ID:          toGlobalId("Card", vert.URLName),
 			DocType:     "card",
 			Title:       vert.DisplayName,
-			Headline:    "Learn " + vert.DisplayName,
-			TextContent: cardText.String(),
+			Headline:    vert.headlineOr(vert.DisplayName),
+			TextContent: cardTextContent,
 			CodeContent: cardCode.String(),
 			CourseId:    courseID,
 			UnitId:      unitID,
 			SectionId:   sequential.URLName,
 			CardId:      vert.URLName,
+			Tags:        vert.Tags,
 		}
 		esearchdocs = append(esearchdocs, esearchdoc)
 	}
+	section.EstMinutes = sequential.EstMinutes
+	if section.EstMinutes == 0 {
+		for _, card := range section.Cards.Cards {
+			section.EstMinutes += card.EstMinutes
+		}
+	}
+	stampTimes(&section, sequential.CreatedAt, sequential.UpdatedAt)
 
 	esearchdoc := &esmodels.ElasticsearchGenDoc{
-		ID:        toGlobalId("Section", section.ID),
-		DocType:   "section",
-		Title:     sequential.DisplayName,
-		Headline:  "Learn " + sequential.DisplayName,
-		CourseId:  courseID,
-		UnitId:    unitID,
-		SectionId: section.ID,
+		ID:          toGlobalId("Section", section.ID),
+		DocType:     "section",
+		Title:       sequential.DisplayName,
+		Headline:    sequential.headlineOr(sequential.DisplayName),
+		TextContent: sequential.Description,
+		CourseId:    courseID,
+		UnitId:      unitID,
+		SectionId:   section.ID,
+		Tags:        sequential.Tags,
 	}
 	esearchdocs = append(esearchdocs, esearchdoc)
 	return
@@ -1001,17 +1318,36 @@ func exportCourseRecursive(course ir.Course, rootDir string) (err error) {
 		CourseImage: course.GetCourseImage(),
 		Language:    course.GetLanguage(),
 	}
-	err = writeIndexYAML(rootDir, courseEOCS)
+	err = courseEOCS.setExtraAttributes(course.GetExtraAttributes())
+	if err != nil {
+		return err
+	}
+	courseEOCS.Assets, err = irAssetsToAssets(course.GetAssets())
 	if err != nil {
 		return err
 	}
-
 	err = appendIRChaptersToCourse(courseEOCS, course.GetChapters())
 	if err != nil {
 		return err
 	}
+	linkStaticAssets(courseEOCS)
+
+	err = writeIndexYAML(rootDir, courseEOCS)
+	if err != nil {
+		return err
+	}
+	err = writeTranslations(rootDir, courseEOCS.Translated)
+	if err != nil {
+		return err
+	}
+	err = writeAssets(rootDir, courseEOCS.Assets)
+	if err != nil {
+		return err
+	}
 	wg := sync.WaitGroup{}
-	for chapIdx, chap := range courseEOCS.Chapters {
+	chapDirs := &exportDirs{dir: rootDir}
+	for _, chap := range courseEOCS.Chapters {
+		chapDir, chapIdx := chapDirs.next(chap.IsDraft)
 		wg.Add(1)
 		go func(rd string, cIdx int, c *Chapter) {
 			Log.Info("Starting to export chapter: ", c.DisplayName)
@@ -1021,7 +1357,7 @@ func exportCourseRecursive(course ir.Course, rootDir string) (err error) {
 				Log.Fatalf("eocs: chapter export routine encountered fatal error: %s", err.Error())
 			}
 			wg.Done()
-		}(rootDir, chapIdx, chap)
+		}(chapDir, chapIdx, chap)
 	}
 	wg.Wait()
 	return nil
@@ -1037,8 +1373,14 @@ func exportChapterRecursive(rootDir string, index int, chap *Chapter) (err error
 	if err != nil {
 		return
 	}
-	for seqIdx, seq := range chap.Sequentials {
-		err = exportSequentialRecursive(dirName, seqIdx, seq)
+	err = writeTranslations(dirName, chap.Translated)
+	if err != nil {
+		return
+	}
+	seqDirs := &exportDirs{dir: dirName}
+	for _, seq := range chap.Sequentials {
+		seqDir, seqIdx := seqDirs.next(seq.IsDraft)
+		err = exportSequentialRecursive(seqDir, seqIdx, seq)
 		if err != nil {
 			return
 		}
@@ -1056,8 +1398,14 @@ func exportSequentialRecursive(rootDir string, index int, seq *Sequential) (err
 	if err != nil {
 		return
 	}
-	for vertIdx, vert := range seq.Verticals {
-		err = exportVerticalRecursive(dirName, vertIdx, vert)
+	err = writeTranslations(dirName, seq.Translated)
+	if err != nil {
+		return
+	}
+	vertDirs := &exportDirs{dir: dirName}
+	for _, vert := range seq.Verticals {
+		vertDir, vertIdx := vertDirs.next(vert.IsDraft)
+		err = exportVerticalRecursive(vertDir, vertIdx, vert)
 		if err != nil {
 			return
 		}
@@ -1075,6 +1423,14 @@ func exportVerticalRecursive(rootDir string, index int, vert *Vertical) (err err
 	if err != nil {
 		return
 	}
+	err = writeTranslations(dirName, vert.Translated)
+	if err != nil {
+		return
+	}
+	err = writeAssets(dirName, vert.Assets)
+	if err != nil {
+		return
+	}
 
 	for blkIdx, blk := range vert.Blocks {
 		err = exportBlock(dirName, blkIdx, blk)
@@ -1102,6 +1458,35 @@ func exportBlock(rootDir string, index int, blk *Block) (err error) {
 	if err != nil {
 		return err
 	}
+	translated := make(map[string]string, len(blk.Translated))
+	for locale, trContents := range blk.Translated {
+		translated[locale] = trContents
+	}
+	if blk.GetBlockType() == "problem" && blk.REPL != nil {
+		replBaseName := concatDirName(index, blk.DisplayName) + ".prob"
+		err = blk.MarshalREPL(rootDir, replBaseName)
+		if err != nil {
+			return err
+		}
+		// Point the answer shebang at the REPL config that was just written next to the problem
+		shebang := fmt.Sprintf("#!exl::repl('%s.repl.yaml')", replBaseName)
+		contents = problemREPLShebangRegex.ReplaceAllLiteralString(contents, shebang)
+		for locale, trContents := range translated {
+			translated[locale] = problemREPLShebangRegex.ReplaceAllLiteralString(trContents, shebang)
+		}
+	}
+	for locale, trContents := range translated {
+		err = ioutil.WriteFile(translationFileName(fileName, locale), []byte(trContents), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	if blk.GetBlockType() == "problem" {
+		contents, err = blk.Meta.withFrontMatter(contents)
+		if err != nil {
+			return err
+		}
+	}
 	err = ioutil.WriteFile(fileName, []byte(contents), 0755)
 	if err != nil {
 		return err
@@ -1109,6 +1494,12 @@ func exportBlock(rootDir string, index int, blk *Block) (err error) {
 	return nil
 }
 
+// Defaults of the course settings that index.yaml may leave out
+const (
+	defaultSubscriptionLevel = 1
+	defaultVerifiedCertCost  = 30
+)
+
 type Course struct {
 	URLName           string                      `yaml:"url_name"`
 	DisplayName       string                      `yaml:"display_name"`
@@ -1126,8 +1517,19 @@ type Course struct {
 	Weight            int                         `yaml:"weight"`
 	EstMinutes        int                         `yaml:"est_minutes"`
 	InstructorTimekit *esmodels.InstructorTimekit `yaml:"instructor_timekit"`
-	Chapters          []*Chapter                  `yaml:"-"`
-	ContentUpdatedAt  time.Time                   `yaml:"-"`
+	// The publishing and commerce settings are left out of index.yaml when they have their default values
+	IsPublished        *bool    `yaml:"is_published,omitempty"`
+	SubscriptionLevel  int      `yaml:"subscription_level,omitempty"`
+	VerifiedCertCost   *float64 `yaml:"verified_cert_cost,omitempty"`
+	IsOrganizationOnly bool     `yaml:"is_organization_only,omitempty"`
+	OrganizationIDs    []string `yaml:"organization_ids,flow,omitempty"`
+	// TranslationLocales are the languages that the course is translated to with overlays next to the original files
+	TranslationLocales []string                `yaml:"translations,flow,omitempty"`
+	Translated         map[string]*Translation `yaml:"-"`
+	Chapters           []*Chapter              `yaml:"-"`
+	ContentUpdatedAt   time.Time               `yaml:"-"`
+	ContentCreatedAt   time.Time               `yaml:"-"`
+	Assets             []*Asset                `yaml:"-"`
 }
 
 func (course *Course) GetDisplayName() string {
@@ -1168,23 +1570,147 @@ func (course *Course) GetSkillLevel() (i int, err error) {
 
 func (course *Course) GetExtraAttributes() map[string]string {
 	extraAttrTK, _ := json.Marshal(course.InstructorTimekit)
-	return map[string]string{
-		"info_md":            course.InfoMD,
-		"description":        course.Description,
-		"headline":           course.Headline,
-		"topics":             concatExtraAttrCSV(course.Topics),
-		"primary_topic":      course.PrimaryTopic,
-		"repo_url":           course.RepoURL,
-		"instructor_timekit": string(extraAttrTK),
-		"est_minutes":        strconv.Itoa(course.EstMinutes),
-		"weight":             strconv.Itoa(course.Weight),
+	attrs := map[string]string{
+		"info_md":              course.InfoMD,
+		"description":          course.Description,
+		"headline":             course.Headline,
+		"topics":               concatExtraAttrCSV(course.Topics),
+		"primary_topic":        course.PrimaryTopic,
+		"repo_url":             course.RepoURL,
+		"instructor_timekit":   string(extraAttrTK),
+		"est_minutes":          strconv.Itoa(course.EstMinutes),
+		"weight":               strconv.Itoa(course.Weight),
+		"skill_level":          course.SkillLevel,
+		"is_published":         formatOptionalBool(course.IsPublished),
+		"subscription_level":   formatOptionalInt(course.SubscriptionLevel),
+		"verified_cert_cost":   formatOptionalFloat(course.VerifiedCertCost),
+		"is_organization_only": strconv.FormatBool(course.IsOrganizationOnly),
+		"organization_ids":     concatExtraAttrCSV(course.OrganizationIDs),
+		"translations":         concatExtraAttrCSV(course.TranslationLocales),
+	}
+	translationExtraAttributes(course.Translated, attrs)
+	return attrs
+}
+
+// setExtraAttributes is the reverse of GetExtraAttributes, so that the course details are kept when exporting to EOCS
+func (course *Course) setExtraAttributes(attrs map[string]string) error {
+	course.InfoMD = attrs["info_md"]
+	course.Description = attrs["description"]
+	course.Headline = attrs["headline"]
+	if attrs["topics"] != "" {
+		course.Topics = extraAttrCSVToStrSlice(attrs["topics"])
+	}
+	course.PrimaryTopic = attrs["primary_topic"]
+	course.RepoURL = attrs["repo_url"]
+	course.SkillLevel = attrs["skill_level"]
+	if estMinutes, err := strconv.Atoi(attrs["est_minutes"]); err == nil {
+		course.EstMinutes = estMinutes
+	}
+	if weight, err := strconv.Atoi(attrs["weight"]); err == nil {
+		course.Weight = weight
+	}
+	if tk := attrs["instructor_timekit"]; tk != "" && tk != "null" {
+		course.InstructorTimekit = &esmodels.InstructorTimekit{}
+		err := json.Unmarshal([]byte(tk), course.InstructorTimekit)
+		if err != nil {
+			return err
+		}
+	}
+	if v := attrs["is_published"]; v != "" {
+		isPublished, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid is_published value: %s", v))
+		}
+		course.IsPublished = &isPublished
 	}
+	if v := attrs["subscription_level"]; v != "" {
+		subscriptionLevel, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid subscription_level value: %s", v))
+		}
+		course.SubscriptionLevel = subscriptionLevel
+	}
+	if v := attrs["verified_cert_cost"]; v != "" {
+		certCost, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid verified_cert_cost value: %s", v))
+		}
+		course.VerifiedCertCost = &certCost
+	}
+	if v := attrs["is_organization_only"]; v != "" {
+		orgOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid is_organization_only value: %s", v))
+		}
+		course.IsOrganizationOnly = orgOnly
+	}
+	if attrs["organization_ids"] != "" {
+		course.OrganizationIDs = extraAttrCSVToStrSlice(attrs["organization_ids"])
+	}
+	if v := attrs["translations"]; v != "" {
+		course.TranslationLocales = extraAttrCSVToStrSlice(v)
+	}
+	course.Translated = translationsFromExtraAttributes(attrs)
+	err := course.validateSettings()
+	if err != nil {
+		return err
+	}
+	return course.validateTranslations()
+}
+
+// validateSettings checks the publishing and commerce settings of index.yaml
+func (course *Course) validateSettings() error {
+	if course.SubscriptionLevel < 0 {
+		return errors.New(fmt.Sprintf("invalid subscription_level value in index.yaml: %d, it must not be negative", course.SubscriptionLevel))
+	}
+	if course.VerifiedCertCost != nil && *course.VerifiedCertCost < 0 {
+		return errors.New(fmt.Sprintf("invalid verified_cert_cost value in index.yaml: %v, it must not be negative", *course.VerifiedCertCost))
+	}
+	for _, orgID := range course.OrganizationIDs {
+		if strings.TrimSpace(orgID) == "" {
+			return errors.New("invalid organization_ids in index.yaml: the IDs must not be empty")
+		}
+	}
+	if course.IsOrganizationOnly && len(course.OrganizationIDs) == 0 {
+		return errors.New("is_organization_only is set in index.yaml, but there are no organization_ids for the course to be visible to")
+	}
+	return nil
+}
+
+// GetIsPublished defaults to true, so that a course is only staged unpublished when index.yaml says so
+func (course *Course) GetIsPublished() bool {
+	if course.IsPublished == nil {
+		return true
+	}
+	return *course.IsPublished
+}
+
+func (course *Course) GetSubscriptionLevel() int {
+	if course.SubscriptionLevel == 0 {
+		return defaultSubscriptionLevel
+	}
+	return course.SubscriptionLevel
+}
+
+func (course *Course) GetVerifiedCertCost() float64 {
+	if course.VerifiedCertCost == nil {
+		return defaultVerifiedCertCost
+	}
+	return *course.VerifiedCertCost
 }
 
 func (course *Course) GetChapters() []ir.Chapter {
 	return chaptersToIRChapters(course.Chapters)
 }
 
+func (course *Course) GetAssets() []ir.Asset {
+	return assetsToIRAssets(course.Assets)
+}
+
 func (course *Course) SetContentUpdatedAt(updatedAt time.Time) {
 	course.ContentUpdatedAt = updatedAt
 }
+
+func (course *Course) SetContentCreatedAt(createdAt time.Time) {
+	course.ContentCreatedAt = createdAt
+}
@@ -0,0 +1,173 @@
+package eocs
+
+import (
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// createdAtMatchKeys are the fields that identify an element of a list between the record and its stored document,
+// elements without any of them are matched by position
+var createdAtMatchKeys = []string{"_id", "doc_id", "locale"}
+
+// keepCreatedAt carries the creation times of the stored document over into the record that replaces it, so that they
+// stay the time the course object was first loaded. The creation times that the stored document does not have are kept
+// as converted (from the first git commit when known), or set to now if the conversion left them empty
+func keepCreatedAt(store courseStore, collection, id string, record interface{}) error {
+	stored := bson.M{}
+	found, err := store.FindRecord(collection, id, &stored)
+	if err != nil {
+		return err
+	}
+	if !found {
+		stored = nil
+	}
+	copyCreatedAt(reflect.ValueOf(record), stored, time.Now())
+	return nil
+}
+
+// copyCreatedAt walks the record along with its stored BSON document, matching the struct fields by their bson names
+func copyCreatedAt(v reflect.Value, stored interface{}, now time.Time) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			copyCreatedAt(v.Elem(), stored, now)
+		}
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		// The value in an interface can't be modified in place, so a copy is walked and put back
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		copyCreatedAt(elem, stored, now)
+		v.Set(elem)
+	case reflect.Slice:
+		storedElems := map[string]interface{}{}
+		if list, ok := stored.([]interface{}); ok {
+			for i, s := range list {
+				storedElems[storedElemKey(s, i)] = s
+			}
+		}
+		for i := 0; i < v.Len(); i++ {
+			copyCreatedAt(v.Index(i), storedElems[elemKey(v.Index(i), i)], now)
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		doc := asBSONDoc(stored)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := bsonFieldName(field)
+			if field.Name == "CreatedAt" && field.Type == timeType {
+				if t, ok := doc[name].(time.Time); ok && !t.IsZero() {
+					v.Field(i).Set(reflect.ValueOf(t))
+				} else if v.Field(i).Interface().(time.Time).IsZero() {
+					v.Field(i).Set(reflect.ValueOf(now))
+				}
+				continue
+			}
+			copyCreatedAt(v.Field(i), doc[name], now)
+		}
+	}
+}
+
+// stampTimes sets the created_at and updated_at that the conversion left empty in the record and the objects embedded in
+// it (mostly its intl strings) to the times of the course object that it is converted from, so that converting the same
+// content twice gives the same record
+func stampTimes(record interface{}, createdAt, updatedAt time.Time) {
+	stampTimeFields(reflect.ValueOf(record), createdAt, updatedAt)
+}
+
+func stampTimeFields(v reflect.Value, createdAt, updatedAt time.Time) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			stampTimeFields(v.Elem(), createdAt, updatedAt)
+		}
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		stampTimeFields(elem, createdAt, updatedAt)
+		v.Set(elem)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			stampTimeFields(v.Index(i), createdAt, updatedAt)
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" || !v.Field(i).CanSet() {
+				continue
+			}
+			if field.Type == timeType && (field.Name == "CreatedAt" || field.Name == "UpdatedAt") {
+				if v.Field(i).Interface().(time.Time).IsZero() {
+					if field.Name == "CreatedAt" {
+						v.Field(i).Set(reflect.ValueOf(createdAt))
+					} else {
+						v.Field(i).Set(reflect.ValueOf(updatedAt))
+					}
+				}
+				continue
+			}
+			stampTimeFields(v.Field(i), createdAt, updatedAt)
+		}
+	}
+}
+
+func asBSONDoc(v interface{}) bson.M {
+	switch tv := v.(type) {
+	case bson.M:
+		return tv
+	case map[string]interface{}:
+		return tv
+	}
+	return nil
+}
+
+// bsonFieldName is the key of the field in the document, which mgo defaults to the lowercased field name
+func bsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("bson"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func elemKey(v reflect.Value, position int) string {
+	if v.Kind() == reflect.Struct {
+		for _, key := range createdAtMatchKeys {
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" && bsonFieldName(v.Type().Field(i)) == key {
+					return key + ":" + fmt.Sprint(v.Field(i).Interface())
+				}
+			}
+		}
+	}
+	return strconv.Itoa(position)
+}
+
+func storedElemKey(v interface{}, position int) string {
+	doc := asBSONDoc(v)
+	for _, key := range createdAtMatchKeys {
+		if val, ok := doc[key]; ok {
+			return key + ":" + fmt.Sprint(val)
+		}
+	}
+	return strconv.Itoa(position)
+}
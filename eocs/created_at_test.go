@@ -0,0 +1,144 @@
+package eocs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type createdAtTestString struct {
+	Locale    string    `bson:"locale"`
+	Content   string    `bson:"content"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type createdAtTestItem struct {
+	Text      string
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type createdAtTestRecord struct {
+	ID        string                `bson:"_id"`
+	Title     []createdAtTestString `bson:"title"`
+	Items     []createdAtTestItem   `bson:"items"`
+	Data      interface{}           `bson:"data"`
+	CreatedAt time.Time             `bson:"created_at"`
+	UpdatedAt time.Time             `bson:"updated_at"`
+}
+
+func TestKeepCreatedAt(t *testing.T) {
+	storedAt := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	convertedAt := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		stored *createdAtTestRecord
+		record *createdAtTestRecord
+		// want returns the fields with their expected times, a zero time is expected to be the current time
+		want func(rec *createdAtTestRecord) map[string][2]time.Time
+	}{
+		{
+			name:   "a record that is not stored keeps its converted times",
+			record: &createdAtTestRecord{ID: "r1", CreatedAt: convertedAt, UpdatedAt: updatedAt},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"created": {rec.CreatedAt, convertedAt},
+					"updated": {rec.UpdatedAt, updatedAt},
+				}
+			},
+		},
+		{
+			name:   "a record that is not stored and has no times gets the current time",
+			record: &createdAtTestRecord{ID: "r1", Items: []createdAtTestItem{{Text: "a"}}},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"created":      {rec.CreatedAt, time.Time{}},
+					"item created": {rec.Items[0].CreatedAt, time.Time{}},
+				}
+			},
+		},
+		{
+			name:   "the stored creation time is kept, the update time is not",
+			stored: &createdAtTestRecord{ID: "r1", CreatedAt: storedAt, UpdatedAt: storedAt},
+			record: &createdAtTestRecord{ID: "r1", CreatedAt: convertedAt, UpdatedAt: updatedAt},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"created": {rec.CreatedAt, storedAt},
+					"updated": {rec.UpdatedAt, updatedAt},
+				}
+			},
+		},
+		{
+			name: "list elements are matched by locale",
+			stored: &createdAtTestRecord{ID: "r1", CreatedAt: storedAt, Title: []createdAtTestString{
+				{Locale: "en", Content: "Loops", CreatedAt: storedAt},
+				{Locale: "es", Content: "Bucles", CreatedAt: storedAt.Add(time.Hour)},
+			}},
+			record: &createdAtTestRecord{ID: "r1", CreatedAt: convertedAt, Title: []createdAtTestString{
+				{Locale: "es", Content: "Ciclos", CreatedAt: convertedAt},
+				{Locale: "fr", Content: "Boucles", CreatedAt: convertedAt},
+				{Locale: "en", Content: "Loops", CreatedAt: convertedAt},
+			}},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"es created": {rec.Title[0].CreatedAt, storedAt.Add(time.Hour)},
+					"fr created": {rec.Title[1].CreatedAt, convertedAt},
+					"en created": {rec.Title[2].CreatedAt, storedAt},
+				}
+			},
+		},
+		{
+			name: "list elements without a key are matched by position",
+			stored: &createdAtTestRecord{ID: "r1", CreatedAt: storedAt, Items: []createdAtTestItem{
+				{Text: "a", CreatedAt: storedAt},
+			}},
+			record: &createdAtTestRecord{ID: "r1", CreatedAt: convertedAt, Items: []createdAtTestItem{
+				{Text: "changed", CreatedAt: convertedAt},
+				{Text: "new", CreatedAt: convertedAt},
+			}},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"first item created":  {rec.Items[0].CreatedAt, storedAt},
+					"second item created": {rec.Items[1].CreatedAt, convertedAt},
+				}
+			},
+		},
+		{
+			name:   "a struct in an interface",
+			stored: &createdAtTestRecord{ID: "r1", CreatedAt: storedAt, Data: createdAtTestItem{Text: "a", CreatedAt: storedAt}},
+			record: &createdAtTestRecord{ID: "r1", CreatedAt: convertedAt, Data: createdAtTestItem{Text: "a", CreatedAt: convertedAt}},
+			want: func(rec *createdAtTestRecord) map[string][2]time.Time {
+				return map[string][2]time.Time{
+					"data created": {rec.Data.(createdAtTestItem).CreatedAt, storedAt},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "eocs-created-at")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			store, err := newCourseStore(jsonFileStoreURIPrefix + dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.stored != nil {
+				err = store.UpsertRecord("test", tt.stored.ID, tt.stored)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = keepCreatedAt(store, "test", tt.record.ID, tt.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, times := range tt.want(tt.record) {
+				checkTime(t, name, times[0], times[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package eocs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// draftsDirName is the folder of the course, a chapter or a sequential that holds its unpublished chapters, sequentials
+// or verticals respectively, in the same layout as the published ones
+const draftsDirName = "drafts"
+
+// outlinePathParts splits the path of a directory of the course into the chapter, sequential and vertical directories
+// that it is made of, leaving out the drafts folders. isDraft tells whether the directory is in a drafts folder itself
+func outlinePathParts(relPath string) (parts []string, isDraft bool) {
+	allParts := strings.Split(relPath, string(filepath.Separator))
+	for i, part := range allParts {
+		if part == draftsDirName {
+			isDraft = i == len(allParts)-2
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts, isDraft
+}
+
+// leaveOutDrafts removes the drafts from the course, along with everything in them
+func leaveOutDrafts(course *Course) {
+	left := 0
+	chaps := course.Chapters[:0]
+	for _, chap := range course.Chapters {
+		if chap.IsDraft {
+			left++
+			continue
+		}
+		seqs := chap.Sequentials[:0]
+		for _, seq := range chap.Sequentials {
+			if seq.IsDraft {
+				left++
+				continue
+			}
+			verts := seq.Verticals[:0]
+			for _, vert := range seq.Verticals {
+				if vert.IsDraft {
+					left++
+					continue
+				}
+				verts = append(verts, vert)
+			}
+			seq.Verticals = verts
+			seqs = append(seqs, seq)
+		}
+		chap.Sequentials = seqs
+		chap.Index = len(chaps)
+		chaps = append(chaps, chap)
+	}
+	course.Chapters = chaps
+	if left > 0 {
+		Log.Infof("%d drafts of course %s are left out, use --include-drafts to load them", left, course.URLName)
+	}
+}
+
+// exportDirs numbers the chapters, sequentials or verticals exported into a directory, keeping the drafts apart in
+// its drafts folder
+type exportDirs struct {
+	dir       string
+	published int
+	drafts    int
+}
+
+// next returns the directory and index of the next item
+func (d *exportDirs) next(isDraft bool) (string, int) {
+	if isDraft {
+		d.drafts++
+		return filepath.Join(d.dir, draftsDirName), d.drafts - 1
+	}
+	d.published++
+	return d.dir, d.published - 1
+}
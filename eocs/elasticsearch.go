@@ -0,0 +1,277 @@
+package eocs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/config"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/olivere/elastic"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// esLanguageAnalyzers maps the course language to the built-in Elasticsearch language analyzer, anything else falls
+// back to the standard analyzer
+var esLanguageAnalyzers = map[string]string{
+	"ar": "arabic",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"hi": "hindi",
+	"it": "italian",
+	"ja": "cjk",
+	"ko": "cjk",
+	"nl": "dutch",
+	"pt": "portuguese",
+	"ru": "russian",
+	"tr": "turkish",
+	"zh": "cjk",
+}
+
+func newElasticsearchClient(elasticsearchURI string) (*elastic.Client, error) {
+	u, err := url.Parse(elasticsearchURI)
+	if err != nil {
+		Log.Errorf("Elasticsearch URI is invalid: %v. Parsing error: %s", elasticsearchURI, err.Error())
+		return nil, err
+	}
+	var elasticSearchClient *elastic.Client
+	if u.Scheme == "https" && !config.Cfg().IsProductionMode() {
+		// This is used for testing HTTPS backends bypassing Certificate validation
+		// Set ENV MODE=debug
+		tr := &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+		client := &http.Client{Transport: tr}
+		elasticSearchClient, err = elastic.NewClient(elastic.SetHttpClient(client), elastic.SetSniff(false), elastic.SetURL(elasticsearchURI))
+	} else {
+		// This is used in Production and for HTTP backend testing
+		elasticSearchClient, err = elastic.NewClient(elastic.SetSniff(false), elastic.SetURL(elasticsearchURI))
+	}
+	if err != nil {
+		Log.Errorf("Elasticsearch connection issue for URI: %v. Error: %s", elasticsearchURI, err.Error())
+		return nil, err
+	}
+	Log.Info("Elasticsearch connected ", elasticSearchClient)
+	return elasticSearchClient, nil
+}
+
+func esAnalyzerForLanguage(lang string) string {
+	lang = strings.ToLower(strings.SplitN(strings.Replace(lang, "_", "-", -1), "-", 2)[0])
+	if analyzer, ok := esLanguageAnalyzers[lang]; ok {
+		return analyzer
+	}
+	return "standard"
+}
+
+// esIndexBody returns the settings and mapping of the search index for a course language
+func esIndexBody(lang string) map[string]interface{} {
+	analyzer := esAnalyzerForLanguage(lang)
+	textField := map[string]interface{}{"type": "text", "analyzer": analyzer}
+	keywordField := map[string]interface{}{"type": "keyword"}
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"_doc": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"doc_type":     keywordField,
+					"title":        textField,
+					"headline":     textField,
+					"text_content": textField,
+					// Code is not natural language, so stemming/stop words would only get in the way
+					"code_content": map[string]interface{}{"type": "text", "analyzer": "standard"},
+					"course_id":    keywordField,
+					"unit_id":      keywordField,
+					"section_id":   keywordField,
+					"card_id":      keywordField,
+					"tags":         keywordField,
+				},
+			},
+		},
+	}
+}
+
+// newVersionedIndexName returns the name of a concrete index behind the alias, the timestamp keeps them unique
+func newVersionedIndexName(alias string) string {
+	return fmt.Sprintf("%s_%d", alias, time.Now().Unix())
+}
+
+func createSearchIndex(client *elastic.Client, indexName, lang string) error {
+	res, err := client.CreateIndex(indexName).BodyJson(esIndexBody(lang)).Do(context.Background())
+	if err != nil {
+		Log.Errorf("Elasticsearch error creating index %s: %s", indexName, err.Error())
+		return err
+	}
+	if !res.Acknowledged {
+		return errors.New(fmt.Sprintf("elasticsearch did not acknowledge the creation of index %s", indexName))
+	}
+	return nil
+}
+
+// ensureSearchIndex bootstraps the search index when neither an index nor an alias exists under the alias name. The
+// documents are always written through the alias, which points at a versioned index so that it can be swapped later
+func ensureSearchIndex(client *elastic.Client, alias, lang string) error {
+	exists, err := client.IndexExists(alias).Do(context.Background())
+	if err != nil {
+		Log.Errorf("Elasticsearch error checking for index %s: %s", alias, err.Error())
+		return err
+	}
+	if exists {
+		return nil
+	}
+	indexName := newVersionedIndexName(alias)
+	Log.Infof("Elasticsearch index %s does not exist, creating %s with the %s analyzer", alias, indexName, esAnalyzerForLanguage(lang))
+	err = createSearchIndex(client, indexName, lang)
+	if err != nil {
+		return err
+	}
+	_, err = client.Alias().Add(indexName, alias).Do(context.Background())
+	if err != nil {
+		Log.Errorf("Elasticsearch error adding alias %s to %s: %s", alias, indexName, err.Error())
+	}
+	return err
+}
+
+// reindexSearchAlias creates a fresh index with the current settings and mapping, copies over the documents of the
+// current index and then atomically points the alias at it, so searches keep working throughout. An index that was
+// created before aliases were used is deleted right before the alias is added, which is the only moment of downtime
+func reindexSearchAlias(client *elastic.Client, alias, lang string) error {
+	ctx := context.Background()
+	exists, err := client.IndexExists(alias).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ensureSearchIndex(client, alias, lang)
+	}
+	aliasesRes, err := client.Aliases().Index("_all").Do(ctx)
+	if err != nil {
+		return err
+	}
+	oldIndices := aliasesRes.IndicesByAlias(alias)
+	legacyIndex := len(oldIndices) == 0
+	newIndex := newVersionedIndexName(alias)
+	Log.Infof("Reindexing Elasticsearch alias %s into %s", alias, newIndex)
+	err = createSearchIndex(client, newIndex, lang)
+	if err != nil {
+		return err
+	}
+	reindexRes, err := client.Reindex().SourceIndex(alias).DestinationIndex(newIndex).Refresh("true").Do(ctx)
+	if err != nil {
+		Log.Errorf("Elasticsearch error reindexing %s into %s: %s", alias, newIndex, err.Error())
+		return err
+	}
+	if len(reindexRes.Failures) > 0 {
+		return errors.New(fmt.Sprintf("elasticsearch reindex of %s into %s had %d failures", alias, newIndex, len(reindexRes.Failures)))
+	}
+	Log.Infof("Elasticsearch copied %d documents into %s", reindexRes.Total, newIndex)
+	if legacyIndex {
+		_, err = client.DeleteIndex(alias).Do(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = client.Alias().Add(newIndex, alias).Do(ctx)
+		return err
+	}
+	_, err = client.Alias().Action(
+		elastic.NewAliasRemoveAction(alias).Index(oldIndices...),
+		elastic.NewAliasAddAction(alias).Index(newIndex),
+	).Do(ctx)
+	if err != nil {
+		Log.Errorf("Elasticsearch error swapping alias %s to %s: %s", alias, newIndex, err.Error())
+		return err
+	}
+	_, err = client.DeleteIndex(oldIndices...).Do(ctx)
+	if err != nil {
+		// The alias already points at the new index, so the old ones can be cleaned up by hand
+		Log.Errorf("Elasticsearch error removing the old indices %v: %s", oldIndices, err.Error())
+	}
+	return nil
+}
+
+// findSearchDocIDs scrolls through the docs of the index that match the query, returning their IDs
+func findSearchDocIDs(client *elastic.Client, index string, query elastic.Query) ([]string, error) {
+	ctx := context.Background()
+	scroll := client.Scroll(index).Query(query).Size(1000).FetchSource(false)
+	defer scroll.Clear(ctx)
+	var ids []string
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return ids, nil
+		}
+		if elastic.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			Log.Errorf("Elasticsearch error listing the docs of index %s: %s", index, err.Error())
+			return nil, err
+		}
+		for _, hit := range res.Hits.Hits {
+			ids = append(ids, hit.Id)
+		}
+	}
+}
+
+// bulkIndexSearchDocs loads the docs through the bulk processor, which sends ELASTICSEARCH_BULK_SIZE docs per request and
+// retries failed requests with an exponential backoff of up to ELASTICSEARCH_RETRY_SECS
+func bulkIndexSearchDocs(client *elastic.Client, index string, docs []*esmodels.ElasticsearchGenDoc) error {
+	var failuresMutex sync.Mutex
+	var failures []string
+	batchSize := config.Cfg().ElasticsearchBulkSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	maxRetryWait := time.Duration(config.Cfg().ElasticsearchRetrySecs) * time.Second
+	if maxRetryWait <= 0 {
+		maxRetryWait = time.Minute
+	}
+	bp, err := client.BulkProcessor().
+		Name("eocs-search-docs").
+		Workers(1).
+		BulkActions(batchSize).
+		Backoff(elastic.NewExponentialBackoff(100*time.Millisecond, maxRetryWait)).
+		Stats(true).
+		After(func(executionId int64, requests []elastic.BulkableRequest, response *elastic.BulkResponse, err error) {
+			failuresMutex.Lock()
+			defer failuresMutex.Unlock()
+			if err != nil {
+				failures = append(failures, err.Error())
+				return
+			}
+			if response == nil {
+				return
+			}
+			for _, item := range response.Failed() {
+				reason := "unknown"
+				if item.Error != nil {
+					reason = item.Error.Reason
+				}
+				failures = append(failures, fmt.Sprintf("doc %s: %s", item.Id, reason))
+			}
+		}).
+		Do(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, esd := range docs {
+		Log.Debugf("Loading doc ID %v type %v title %v", esd.ID, esd.DocType, esd.Title)
+		bp.Add(elastic.NewBulkIndexRequest().Index(index).Type("_doc").Id(esd.ID).Doc(esd))
+	}
+	err = bp.Close()
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		Log.Errorf("Elasticsearch bulk indexing failures: %v", failures)
+		return errors.New(fmt.Sprintf("elasticsearch bulk indexing of %d documents had %d failures", len(docs), len(failures)))
+	}
+	stats := bp.Stats()
+	Log.Infof("Elasticsearch: indexed %v documents in %v bulk requests", stats.Indexed, stats.Committed)
+	return nil
+}
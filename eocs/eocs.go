@@ -7,6 +7,7 @@ import (
 	"github.com/exlskills/eocsutil/gitutils"
 	"github.com/exlskills/eocsutil/ir"
 	"os"
+	"strings"
 )
 
 var Log = config.Cfg().GetLogger()
@@ -16,6 +17,20 @@ func NewEOCSFormat() *EOCS {
 }
 
 type EOCS struct {
+	// WriteIDs makes Import persist the url_names assigned to chapters/sequentials/verticals that lack one into their
+	// index.yaml. By default Import never modifies the source tree and the assigned IDs only live in memory
+	WriteIDs bool
+	// IncrementalPush makes Push skip the records whose content did not change since the previous push of the course
+	IncrementalPush bool
+	// PruneOrphans makes Push remove the records of the course that are no longer produced from its content. Otherwise
+	// they are only reported
+	PruneOrphans bool
+	// ReindexSearch makes Push rebuild the Elasticsearch index of the course language with the current mapping before
+	// loading the search docs, swapping the alias over once the copy is complete
+	ReindexSearch bool
+	// IncludeDrafts makes Push load the chapters, sequentials and verticals of the drafts folders too, which is meant for
+	// a preview database. By default they are left out
+	IncludeDrafts bool
 }
 
 func (e *EOCS) Import(fromUri string) (toIntermediateRepresentation ir.Course, err error) {
@@ -23,7 +38,28 @@ func (e *EOCS) Import(fromUri string) (toIntermediateRepresentation ir.Course, e
 	if err != nil {
 		return nil, err
 	}
-	return resolveCourseRecursive(rootDir)
+	return resolveCourseRecursive(rootDir, !e.WriteIDs)
+}
+
+// Renumber rewrites the numeric prefixes of the chapter, sequential and vertical directories of the course to a clean
+// sequence from 00 in their current order, e.g., after items were inserted or moved. The url_names are kept
+func (e *EOCS) Renumber(courseUri string) error {
+	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(courseUri)
+	if err != nil {
+		return err
+	}
+	return renumberCourse(rootDir)
+}
+
+// ImportFromMongo reads the course with the given ID back out of the MGO_DB_NAME database that Push loads courses into
+func (e *EOCS) ImportFromMongo(mongoURI, courseID string) (ir.Course, error) {
+	if config.Cfg().MgoDBName == "" {
+		return nil, errors.New("for a MongoDB course import the MGO_DB_NAME environment variable must be set to the name of the MongoDB database to read from")
+	}
+	if courseID == "" {
+		return nil, errors.New("the ID of the course to import from MongoDB must be given")
+	}
+	return resolveCourseFromMongo(mongoURI, config.Cfg().MgoDBName, courseID)
 }
 
 func (e *EOCS) Export(fromIntermediateRepresentation ir.Course, toUri string, forceExport bool) (err error) {
@@ -41,19 +77,59 @@ func (e *EOCS) Export(fromIntermediateRepresentation ir.Course, toUri string, fo
 }
 
 func (e *EOCS) Push(fromUri, toUri string, isServer bool) error {
-	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(fromUri)
+	// The IDs are persisted when loading to MongoDB, so that the records keep their IDs even if the directories are
+	// renamed. Writing to JSON files is only for inspection, so the source is left alone unless asked otherwise
+	readOnly := strings.HasPrefix(toUri, jsonFileStoreURIPrefix) && !e.WriteIDs
+	course, store, err := loadCourseForPush(fromUri, toUri, isServer, readOnly, e.IncludeDrafts)
 	if err != nil {
 		return err
 	}
-	course, err := resolveCourseRecursive(rootDir)
+	defer store.Close()
+
+	// Load data into MongoDB, or whichever store the URI points to
+	return upsertCourseRecursive(course, store, config.Cfg().ElasticsearchBaseIndex, pushOptions{
+		Incremental:         e.IncrementalPush,
+		Prune:               e.PruneOrphans,
+		ReindexSearch:       e.ReindexSearch,
+		ContentVersionsKept: config.Cfg().ContentVersionsKept,
+	})
+}
+
+// DryRunPush converts the course the same way as Push, but only compares the result to what is in the destination. It
+// returns a report of what a push would add, remove and change, without writing anything to the destination or the
+// course files
+func (e *EOCS) DryRunPush(fromUri, toUri string, isServer bool) (string, error) {
+	course, store, err := loadCourseForPush(fromUri, toUri, isServer, true, e.IncludeDrafts)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer store.Close()
+	diff, err := diffCourseAgainstStore(course, store, config.Cfg().ElasticsearchBaseIndex)
+	if err != nil {
+		return "", err
+	}
+	return diff.String(), nil
+}
+
+func loadCourseForPush(fromUri, toUri string, isServer, readOnly, includeDrafts bool) (*Course, courseStore, error) {
+	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(fromUri)
+	if err != nil {
+		return nil, nil, err
+	}
+	course, err := resolveCourseRecursive(rootDir, readOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !includeDrafts {
+		leaveOutDrafts(course)
+	}
+	useAssetBaseURL(course, config.Cfg().AssetsBaseURL)
 
 	Log.Info("Course import complete!")
 
-	if config.Cfg().MgoDBName == "" {
-		return errors.New("for EOCS course conversion the MGO_DB_NAME environment variable must be set to the name of the MongoDB database to write to")
+	store, err := newCourseStore(toUri)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Set UpdatedAt values based on Git commits
@@ -61,12 +137,17 @@ func (e *EOCS) Push(fromUri, toUri string, isServer bool) error {
 	if err != nil {
 		if isServer {
 			Log.Errorf("Git reader failed with: %s", err.Error())
-			return err
+			store.Close()
+			return nil, nil, err
 		} else {
 			Log.Info("Git reader failed - Timestamps will not be assigned")
 		}
 	}
+	return course, store, nil
+}
 
-	// Load data into MongoDB
-	return upsertCourseRecursive(course, toUri, config.Cfg().MgoDBName, config.Cfg().ElasticsearchURI, config.Cfg().ElasticsearchBaseIndex)
+// IsPushURI returns whether the URI is a destination of Push rather than of Export: mongodb:// loads the course into
+// MongoDB (and Elasticsearch), file+json:// writes the very same records to JSON files in a directory
+func IsPushURI(uri string) bool {
+	return isCourseStoreURI(uri)
 }
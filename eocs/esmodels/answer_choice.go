@@ -5,12 +5,15 @@ import (
 	"time"
 )
 
+// AnswerChoice is a choice of a MCSA, MCMA or DDSA question. The Explanation is shown when the choice is selected, and
+// for MCMA questions the UnselectedExplanation is shown when it is left unselected
 type AnswerChoice struct {
-	ID          bson.ObjectId     `bson:"_id"`
-	Sequence    int               `bson:"seq"`
-	Text        IntlStringWrapper `bson:"text"`
-	IsAnswer    bool              `bson:"is_answer"`
-	Explanation IntlStringWrapper `bson:"explanation"`
-	CreatedAt   time.Time         `bson:"created_at"`
-	UpdatedAt   time.Time         `bson:"updated_at"`
+	ID                    bson.ObjectId     `bson:"_id"`
+	Sequence              int               `bson:"seq"`
+	Text                  IntlStringWrapper `bson:"text"`
+	IsAnswer              bool              `bson:"is_answer"`
+	Explanation           IntlStringWrapper `bson:"explanation"`
+	UnselectedExplanation IntlStringWrapper `bson:"unselected_explanation"`
+	CreatedAt             time.Time         `bson:"created_at"`
+	UpdatedAt             time.Time         `bson:"updated_at"`
 }
@@ -6,6 +6,8 @@ type Card struct {
 	ID            string            `bson:"_id"`
 	Title         IntlStringWrapper `bson:"title"`
 	Headline      IntlStringWrapper `bson:"headline"`
+	Description   IntlStringWrapper `bson:"description"`
+	EstMinutes    int               `bson:"est_minutes"`
 	Index         int               `bson:"index"`
 	ContentID     string            `bson:"content_id"`
 	QuestionIDs   []string          `bson:"question_ids"`
@@ -15,4 +15,8 @@ type Exam struct {
 	RandomOrder    bool      `bson:"random_order"`
 	CreatedAt      time.Time `bson:"created_at"`
 	UpdatedAt      time.Time `bson:"updated_at"`
+	// QuestionIDs are the fixed questions that every learner gets, and Pools the ones that questions are drawn out of,
+	// which is only set when there is a choice. No question is in both, and QuestionCount is the number of questions
+	// each learner gets: the fixed ones plus the Draw of each pool
+	Pools []ExamPool `bson:"pools,omitempty"`
 }
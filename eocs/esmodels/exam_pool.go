@@ -0,0 +1,9 @@
+package esmodels
+
+// ExamPool is a set of equivalent candidate questions of an exam, Draw of which are picked for each learner. Tag is set
+// for the pools drawn from the questions of the exam with that tag, which are not slots of the exam
+type ExamPool struct {
+	QuestionIDs []string `bson:"question_ids"`
+	Draw        int      `bson:"draw"`
+	Tag         string   `bson:"tag,omitempty"`
+}
@@ -1,6 +1,11 @@
 package esmodels
 
-import "math/rand"
+import (
+	"crypto/sha1"
+	"github.com/globalsign/mgo/bson"
+	"math/rand"
+	"strings"
+)
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 const (
@@ -30,3 +35,25 @@ func randStringBytesMaskImpr(n int) string {
 func ESID() string {
 	return randStringBytesMaskImpr(12)
 }
+
+// StableESID derives an ESID-style ID from the given parts, so that the same parts always produce the same ID. This is
+// used for everything that does not have a url_name of its own, which keeps repeated loads of unchanged content identical
+func StableESID(parts ...string) string {
+	sum := stableIDHash(parts)
+	b := make([]byte, 12)
+	for i := range b {
+		b[i] = letterBytes[int(sum[i])%len(letterBytes)]
+	}
+	return string(b)
+}
+
+// StableObjectID is the bson.ObjectId counterpart of StableESID
+func StableObjectID(parts ...string) bson.ObjectId {
+	sum := stableIDHash(parts)
+	return bson.ObjectId(sum[:12])
+}
+
+func stableIDHash(parts []string) [sha1.Size]byte {
+	// The NUL separator keeps e.g. ("ab", "c") and ("a", "bc") apart
+	return sha1.Sum([]byte(strings.Join(parts, "\x00")))
+}
@@ -18,6 +18,8 @@ type IntlString struct {
 	UpdatedAt time.Time `bson:"updated_at"`
 }
 
+// NewIntlStringWrapper returns the string as the default one of the wrapper. It has no timestamps, those are the times of
+// the record that it is put in
 func NewIntlStringWrapper(str, locale string) IntlStringWrapper {
 	return IntlStringWrapper{
 		Strings: []IntlString{
@@ -25,9 +27,41 @@ func NewIntlStringWrapper(str, locale string) IntlStringWrapper {
 				Content:   str,
 				IsDefault: true,
 				Locale:    locale,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
 			},
 		},
 	}
 }
+
+// Default returns the default string of the wrapper, falling back to the first one when none is flagged as default
+func (isw IntlStringWrapper) Default() IntlString {
+	for _, s := range isw.Strings {
+		if s.IsDefault {
+			return s
+		}
+	}
+	if len(isw.Strings) > 0 {
+		return isw.Strings[0]
+	}
+	return IntlString{}
+}
+
+// WithTranslation returns a copy of the wrapper with the string of the locale added as a non-default one, replacing
+// the one it may already have. The translation takes the timestamps of the default string
+func (isw IntlStringWrapper) WithTranslation(str, locale string) IntlStringWrapper {
+	strs := make(IntlStrings, 0, len(isw.Strings)+1)
+	for _, s := range isw.Strings {
+		if s.Locale != locale || s.IsDefault {
+			strs = append(strs, s)
+		}
+	}
+	def := isw.Default()
+	strs = append(strs, IntlString{
+		Content:   str,
+		IsDefault: false,
+		Locale:    locale,
+		CreatedAt: def.CreatedAt,
+		UpdatedAt: def.UpdatedAt,
+	})
+	isw.Strings = strs
+	return isw
+}
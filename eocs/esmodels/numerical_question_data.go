@@ -0,0 +1,15 @@
+package esmodels
+
+import (
+	"github.com/globalsign/mgo/bson"
+)
+
+// NumericalQuestionData holds the answer of a numerical question. Answer is either a number/expression, which is
+// matched within Tolerance (absolute, or relative if it ends in `%`), or a range such as `[5, 7)`
+type NumericalQuestionData struct {
+	ID                bson.ObjectId     `json:"_id" bson:"_id"`
+	Answer            string            `json:"answer" bson:"answer"`
+	Tolerance         string            `json:"tolerance" bson:"tolerance"`
+	AdditionalAnswers []string          `json:"additional_answers" bson:"additional_answers"`
+	Explanation       IntlStringWrapper `json:"explanation" bson:"explanation"`
+}
@@ -0,0 +1,19 @@
+package esmodels
+
+import "time"
+
+// PushStateCollection holds one PushState document per course, recording the content hashes of everything that was
+// written by the last push
+const PushStateCollection = "eocs_push_state"
+
+type PushState struct {
+	ID        string            `bson:"_id"`
+	Records   []PushStateRecord `bson:"records"`
+	UpdatedAt time.Time         `bson:"updated_at"`
+}
+
+type PushStateRecord struct {
+	Kind string `bson:"kind"`
+	ID   string `bson:"id"`
+	Hash string `bson:"hash"`
+}
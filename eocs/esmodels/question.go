@@ -2,19 +2,22 @@ package esmodels
 
 import "time"
 
+// Question is a 'question' record. Hints are all of its hints in the order in which they are revealed, Hint is the last
+// of them
 type Question struct {
-	ID              string            `bson:"id"`
-	Data            interface{}       `bson:"data"`
-	Points          float64           `bson:"points"`
-	ComplexityLevel int               `bson:"compl_level"`
-	QuestionType    string            `bson:"question_type"`
-	QuestionText    IntlStringWrapper `bson:"question_text"`
-	EstTimeSec      int               `bson:"est_time_sec"`
-	Tags            []string          `bson:"tags"`
-	DocRef          DocRef            `bson:"doc_ref"`
-	ExamOnly        bool              `bson:"exam_only"`
-	CourseItemRef   CourseItemRef     `bson:"course_item_ref"`
-	Hint            IntlStringWrapper `bson:"hint"`
-	CreatedAt       time.Time         `bson:"created_at"`
-	UpdatedAt       time.Time         `bson:"updated_at"`
+	ID              string              `bson:"id"`
+	Data            interface{}         `bson:"data"`
+	Points          float64             `bson:"points"`
+	ComplexityLevel int                 `bson:"compl_level"`
+	QuestionType    string              `bson:"question_type"`
+	QuestionText    IntlStringWrapper   `bson:"question_text"`
+	EstTimeSec      int                 `bson:"est_time_sec"`
+	Tags            []string            `bson:"tags"`
+	DocRef          DocRef              `bson:"doc_ref"`
+	ExamOnly        bool                `bson:"exam_only"`
+	CourseItemRef   CourseItemRef       `bson:"course_item_ref"`
+	Hint            IntlStringWrapper   `bson:"hint"`
+	Hints           []IntlStringWrapper `bson:"hints"`
+	CreatedAt       time.Time           `bson:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at"`
 }
@@ -1,10 +1,14 @@
 package esmodels
 
+// olxToQuestionType maps the OLX problem types to the question_type codes of the EXLskills platform. WSCQ, MCSA and
+// MCMA are the codes that the platform already serves. DDSA (dropdown, single answer) and NUMQ (numerical) follow their
+// naming but are not defined in this repository or confirmed by the platform yet, so its question_type enum has to
+// accept them before a course with dropdown or numerical problems is loaded to production
 var olxToQuestionType = map[string]string{
 	"stringresponse":         "WSCQ",
-	"optionresponse":         "", // TODO
+	"optionresponse":         "DDSA",
 	"multiplechoiceresponse": "MCSA",
-	"numericalresponse":      "", // TODO
+	"numericalresponse":      "NUMQ",
 	"choiceresponse":         "MCMA",
 }
 
@@ -5,11 +5,14 @@ import (
 )
 
 type Section struct {
-	ID        string            `bson:"_id"`
-	Title     IntlStringWrapper `bson:"title"`
-	Headline  IntlStringWrapper `bson:"headline"`
-	Index     int               `bson:"index"`
-	Cards     CardsWrapper      `bson:"cards"`
-	CreatedAt time.Time         `bson:"created_at"`
-	UpdatedAt time.Time         `bson:"updated_at"`
+	ID          string            `bson:"_id"`
+	Title       IntlStringWrapper `bson:"title"`
+	Headline    IntlStringWrapper `bson:"headline"`
+	Description IntlStringWrapper `bson:"description"`
+	EstMinutes  int               `bson:"est_minutes"`
+	Tags        []string          `bson:"tags"`
+	Index       int               `bson:"index"`
+	Cards       CardsWrapper      `bson:"cards"`
+	CreatedAt   time.Time         `bson:"created_at"`
+	UpdatedAt   time.Time         `bson:"updated_at"`
 }
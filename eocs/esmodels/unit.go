@@ -9,6 +9,9 @@ type Unit struct {
 	Sections              SectionsWrapper   `bson:"sections"`
 	Title                 IntlStringWrapper `bson:"title"`
 	Headline              IntlStringWrapper `bson:"headline"`
+	Description           IntlStringWrapper `bson:"description"`
+	EstMinutes            int               `bson:"est_minutes"`
+	Tags                  []string          `bson:"tags"`
 	FinalExamIDs          []string          `bson:"final_exams"`
 	Index                 int               `bson:"index"`
 	FinalExamWeightPct    float64           `bson:"final_exam_weight_pct"`
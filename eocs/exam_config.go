@@ -0,0 +1,369 @@
+package eocs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Defaults of the exam settings that the index.yaml of a "Final Exam" sequential may leave out
+const (
+	defaultExamPassMarkPct    = 75
+	defaultExamCreatorID      = "1ejFaqz00nJy" // Sasha Varlamov
+	defaultExamAttemptsPerDay = 2
+	// The time limit is derived from the estimated time of the questions unless it is set
+	defaultExamTimeLimitFactor = 1.5
+)
+
+// ExamConfig is the `exam` section of the index.yaml of a graded "Final Exam" sequential. The attempts per day and the
+// weight apply to the final exams of the whole chapter, the weight being the share of the chapter in the course grade.
+// The chapters without a weight split what the others leave of the 100% equally. Each vertical of the sequential is a
+// slot of the exam, with one question drawn out of its problems, and the pools add questions drawn by tag
+type ExamConfig struct {
+	PassMarkPct    *float64       `yaml:"pass_mark_pct,omitempty"`
+	CreatorID      string         `yaml:"creator_id,omitempty"`
+	TimeLimit      int            `yaml:"time_limit,omitempty"`
+	RandomOrder    bool           `yaml:"random_order,omitempty"`
+	UseIDETestMode *bool          `yaml:"use_ide_test_mode,omitempty"`
+	AttemptsPerDay int            `yaml:"attempts_per_day,omitempty"`
+	WeightPct      *float64       `yaml:"weight_pct,omitempty"`
+	Tags           []string       `yaml:"tags,flow,omitempty"`
+	Pools          []ExamPoolRule `yaml:"pools,omitempty"`
+}
+
+// ExamPoolRule draws questions out of the exam questions with the tag that no other exam uses. These come from the
+// verticals of the final exam whose problems all have the tag of one of its pools, which are not slots of the exam
+type ExamPoolRule struct {
+	Tag  string `yaml:"tag" json:"tag"`
+	Draw int    `yaml:"draw" json:"draw"`
+}
+
+func (seq *Sequential) isFinalExam() bool {
+	return seq.GetIsGraded() && strings.HasPrefix(seq.Format, "Final Exam")
+}
+
+// examConfig returns the exam settings of the sequential, which are all defaults if it has none
+func (seq *Sequential) examConfig() *ExamConfig {
+	if seq.Exam == nil {
+		return &ExamConfig{}
+	}
+	return seq.Exam
+}
+
+// indexYAMLName names the index.yaml of the sequential in errors
+func (seq *Sequential) indexYAMLName() string {
+	if seq.dir == "" {
+		return fmt.Sprintf("index.yaml of sequential %s", seq.URLName)
+	}
+	return filepath.Join(seq.dir, "index.yaml")
+}
+
+func (seq *Sequential) validateExamConfig() error {
+	if seq.Exam == nil {
+		return nil
+	}
+	ex := seq.Exam
+	var problems []string
+	if !seq.isFinalExam() {
+		problems = append(problems, "exam settings are only valid for a graded sequential with a \"Final Exam\" format")
+	}
+	if ex.PassMarkPct != nil && (*ex.PassMarkPct <= 0 || *ex.PassMarkPct > 100) {
+		problems = append(problems, fmt.Sprintf("pass_mark_pct must be more than 0 and at most 100, got %v", *ex.PassMarkPct))
+	}
+	if ex.TimeLimit < 0 {
+		problems = append(problems, fmt.Sprintf("time_limit must be a number of minutes, got %d", ex.TimeLimit))
+	}
+	if ex.AttemptsPerDay < 0 {
+		problems = append(problems, fmt.Sprintf("attempts_per_day must be 1 or more, got %d", ex.AttemptsPerDay))
+	}
+	if ex.WeightPct != nil && (*ex.WeightPct < 0 || *ex.WeightPct > 100) {
+		problems = append(problems, fmt.Sprintf("weight_pct must be between 0 and 100, got %v", *ex.WeightPct))
+	}
+	for _, tag := range ex.Tags {
+		if strings.TrimSpace(tag) == "" {
+			problems = append(problems, "tags must not be empty")
+			break
+		}
+	}
+	for i, rule := range ex.Pools {
+		if strings.TrimSpace(rule.Tag) == "" {
+			problems = append(problems, fmt.Sprintf("pool %d must have a tag", i+1))
+		}
+		if rule.Draw < 1 {
+			problems = append(problems, fmt.Sprintf("pool %d must draw 1 or more questions, got %d", i+1, rule.Draw))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(fmt.Sprintf("invalid exam settings in %s: %s", seq.indexYAMLName(), strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+// chapterExamSettings returns the attempts per day and the weight of the chapter, as set by its final exams
+func chapterExamSettings(chap *Chapter) (attemptsPerDay int, weightPct *float64, err error) {
+	var attemptsFrom, weightFrom *Sequential
+	for _, seq := range chap.Sequentials {
+		if !seq.isFinalExam() {
+			continue
+		}
+		ex := seq.examConfig()
+		if ex.AttemptsPerDay != 0 {
+			if attemptsFrom != nil && ex.AttemptsPerDay != attemptsPerDay {
+				return 0, nil, errors.New(fmt.Sprintf("invalid exam settings in %s: attempts_per_day %d differs from the %d of %s, the final exams of a chapter share it", seq.indexYAMLName(), ex.AttemptsPerDay, attemptsPerDay, attemptsFrom.indexYAMLName()))
+			}
+			attemptsPerDay = ex.AttemptsPerDay
+			attemptsFrom = seq
+		}
+		if ex.WeightPct != nil {
+			if weightFrom != nil && *ex.WeightPct != *weightPct {
+				return 0, nil, errors.New(fmt.Sprintf("invalid exam settings in %s: weight_pct %v differs from the %v of %s, the final exams of a chapter share it", seq.indexYAMLName(), *ex.WeightPct, *weightPct, weightFrom.indexYAMLName()))
+			}
+			weightPct = ex.WeightPct
+			weightFrom = seq
+		}
+	}
+	if attemptsPerDay == 0 {
+		attemptsPerDay = defaultExamAttemptsPerDay
+	}
+	return attemptsPerDay, weightPct, nil
+}
+
+// finalExamWeights returns the weight of each chapter in the course grade
+func finalExamWeights(course *Course) ([]float64, error) {
+	weights := make([]float64, len(course.Chapters))
+	var setTotal float64
+	var setFiles []string
+	unset := 0
+	for i, chap := range course.Chapters {
+		_, weightPct, err := chapterExamSettings(chap)
+		if err != nil {
+			return nil, err
+		}
+		if weightPct == nil {
+			weights[i] = -1
+			unset++
+			continue
+		}
+		weights[i] = *weightPct
+		setTotal += *weightPct
+		for _, seq := range chap.Sequentials {
+			if seq.isFinalExam() && seq.examConfig().WeightPct != nil {
+				setFiles = append(setFiles, seq.indexYAMLName())
+				break
+			}
+		}
+	}
+	// Allow for rounding in weights like 33.33
+	if setTotal > 100.01 {
+		return nil, errors.New(fmt.Sprintf("invalid exam settings: the weight_pct values add up to %v, which is more than 100, in %s", setTotal, strings.Join(setFiles, ", ")))
+	}
+	for i := range weights {
+		if weights[i] < 0 {
+			weights[i] = math.Max(100-setTotal, 0) / float64(unset)
+		}
+	}
+	return weights, nil
+}
+
+// addExamPool adds the questions drawn from the pool to the exam, along with the average time that they take
+func addExamPool(exam *esmodels.Exam, pool esmodels.ExamPool, candidates []*esmodels.Question) {
+	var secs int
+	for _, q := range candidates {
+		secs += q.EstTimeSec
+	}
+	drawnSecs := float64(secs) / float64(len(candidates)) * float64(pool.Draw)
+	exam.EstTime += int(math.Round(drawnSecs / 60))
+	exam.TimeLimit += int(math.Round((drawnSecs * defaultExamTimeLimitFactor) / 60))
+	exam.QuestionCount += pool.Draw
+	exam.Pools = append(exam.Pools, pool)
+}
+
+// isPoolMaterial tells if the problems of an exam vertical all have the tag of one of the pools, in which case they are
+// candidates of the pools drawn by tag rather than a slot of the exam
+func (ex *ExamConfig) isPoolMaterial(qs []*esmodels.Question) bool {
+	if len(ex.Pools) == 0 || len(qs) == 0 {
+		return false
+	}
+	for _, q := range qs {
+		tagged := false
+		for _, rule := range ex.Pools {
+			if containsString(q.Tags, rule.Tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+	return true
+}
+
+// completeExams adds the pools drawn by tag to the final exams, once all the questions of the course are known, and
+// applies the time limits that are set. A pool draws out of the questions of its exam with the tag that are neither
+// slots nor candidates of an earlier pool, so that no question is used by another exam or twice. The questions of the
+// pools that draw all of them become the fixed questions of the exam, only the pools with a choice are kept
+func completeExams(course *Course, exams []*esmodels.Exam, qs []*esmodels.Question) error {
+	examsByID := make(map[string]*esmodels.Exam, len(exams))
+	// The slots of the exams, which the pools drawn by tag must not take
+	used := map[string]bool{}
+	for _, exam := range exams {
+		examsByID[exam.ID] = exam
+		for _, pool := range exam.Pools {
+			for _, id := range pool.QuestionIDs {
+				used[id] = true
+			}
+		}
+	}
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			exam, ok := examsByID[seq.URLName+"_exam"]
+			if !ok || !seq.isFinalExam() {
+				continue
+			}
+			cfg := seq.examConfig()
+			for _, rule := range cfg.Pools {
+				pool := esmodels.ExamPool{Draw: rule.Draw, Tag: rule.Tag}
+				var candidates []*esmodels.Question
+				for _, q := range qs {
+					if q.ExamOnly && q.CourseItemRef.SectionID == seq.URLName && !used[q.ID] && containsString(q.Tags, rule.Tag) {
+						candidates = append(candidates, q)
+						pool.QuestionIDs = append(pool.QuestionIDs, q.ID)
+						used[q.ID] = true
+					}
+				}
+				if len(candidates) < rule.Draw {
+					return errors.New(fmt.Sprintf("invalid exam settings in %s: the pool of tag %q has %d questions that are not slots or in an earlier pool, fewer than the %d to draw", seq.indexYAMLName(), rule.Tag, len(candidates), rule.Draw))
+				}
+				addExamPool(exam, pool, candidates)
+			}
+			if cfg.TimeLimit > 0 {
+				exam.TimeLimit = cfg.TimeLimit
+			}
+			pools := exam.Pools
+			exam.Pools = nil
+			candidates := 0
+			for _, pool := range pools {
+				if len(pool.QuestionIDs) <= pool.Draw {
+					exam.QuestionIDs = append(exam.QuestionIDs, pool.QuestionIDs...)
+					continue
+				}
+				exam.Pools = append(exam.Pools, pool)
+				candidates += len(pool.QuestionIDs)
+			}
+			if len(exam.Pools) > 0 {
+				Log.Infof("Final exam %s: each learner gets %d questions, %d fixed and %d drawn out of %d", exam.ID, exam.QuestionCount, len(exam.QuestionIDs), exam.QuestionCount-len(exam.QuestionIDs), candidates)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (ex *ExamConfig) getPassMarkPct() float64 {
+	if ex.PassMarkPct == nil {
+		return defaultExamPassMarkPct
+	}
+	return *ex.PassMarkPct
+}
+
+func (ex *ExamConfig) getCreatorID() string {
+	if ex.CreatorID == "" {
+		return defaultExamCreatorID
+	}
+	return ex.CreatorID
+}
+
+func (ex *ExamConfig) getUseIDETestMode() bool {
+	if ex.UseIDETestMode == nil {
+		return true
+	}
+	return *ex.UseIDETestMode
+}
+
+// extraAttributes carries the exam settings over to other formats, prefixed with exam_
+func (ex *ExamConfig) extraAttributes() map[string]string {
+	attrs := map[string]string{
+		"exam_pass_mark_pct":     formatOptionalFloat(ex.PassMarkPct),
+		"exam_creator_id":        ex.CreatorID,
+		"exam_time_limit":        formatOptionalInt(ex.TimeLimit),
+		"exam_use_ide_test_mode": formatOptionalBool(ex.UseIDETestMode),
+		"exam_attempts_per_day":  formatOptionalInt(ex.AttemptsPerDay),
+		"exam_weight_pct":        formatOptionalFloat(ex.WeightPct),
+		"exam_tags":              concatExtraAttrCSV(ex.Tags),
+	}
+	if len(ex.Pools) > 0 {
+		pools, _ := json.Marshal(ex.Pools)
+		attrs["exam_pools"] = string(pools)
+	}
+	if ex.RandomOrder {
+		attrs["exam_random_order"] = "true"
+	}
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs
+}
+
+// examConfigFromExtraAttributes is the reverse of extraAttributes, returning nil if there are no exam settings
+func examConfigFromExtraAttributes(attrs map[string]string) (*ExamConfig, error) {
+	ex := &ExamConfig{}
+	found := false
+	for k, v := range attrs {
+		if !strings.HasPrefix(k, "exam_") || v == "" {
+			continue
+		}
+		var err error
+		switch k {
+		case "exam_pass_mark_pct":
+			var f float64
+			f, err = strconv.ParseFloat(v, 64)
+			ex.PassMarkPct = &f
+		case "exam_creator_id":
+			ex.CreatorID = v
+		case "exam_time_limit":
+			ex.TimeLimit, err = strconv.Atoi(v)
+		case "exam_random_order":
+			ex.RandomOrder, err = strconv.ParseBool(v)
+		case "exam_use_ide_test_mode":
+			var b bool
+			b, err = strconv.ParseBool(v)
+			ex.UseIDETestMode = &b
+		case "exam_attempts_per_day":
+			ex.AttemptsPerDay, err = strconv.Atoi(v)
+		case "exam_weight_pct":
+			var f float64
+			f, err = strconv.ParseFloat(v, 64)
+			ex.WeightPct = &f
+		case "exam_tags":
+			ex.Tags = extraAttrCSVToStrSlice(v)
+		case "exam_pools":
+			err = json.Unmarshal([]byte(v), &ex.Pools)
+		default:
+			continue
+		}
+		found = true
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s value: %s", k, v))
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return ex, nil
+}
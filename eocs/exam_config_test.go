@@ -0,0 +1,301 @@
+package eocs
+
+import (
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testProblem(tags ...string) *Block {
+	blk := &Block{BlockType: "problem", Markdown: ">>Which one?<<\n\n(x) This one\n( ) That one\n"}
+	if len(tags) > 0 {
+		blk.Meta = &ProblemMeta{Tags: tags}
+	}
+	return blk
+}
+
+func testVertical(urlName string, blocks ...*Block) *Vertical {
+	return &Vertical{URLName: urlName, DisplayName: urlName, Blocks: blocks}
+}
+
+func testExamSequential(urlName string, pools []ExamPoolRule, verts ...*Vertical) *Sequential {
+	return &Sequential{URLName: urlName, DisplayName: "Final Exam", Graded: true, Format: "Final Exam", Exam: &ExamConfig{Pools: pools}, Verticals: verts}
+}
+
+func testCourse(seqs ...*Sequential) *Course {
+	return &Course{URLName: "course", DisplayName: "Course", Language: "en", Chapters: []*Chapter{{URLName: "chapter", DisplayName: "Chapter", Sequentials: seqs}}}
+}
+
+func TestCompleteExams(t *testing.T) {
+	loops := []ExamPoolRule{{Tag: "loops", Draw: 2}}
+	tests := []struct {
+		name          string
+		course        *Course
+		wantQuestions []string
+		wantPools     []esmodels.ExamPool
+		wantCount     int
+		wantErr       string
+	}{
+		{
+			name:          "slots with a single problem are fixed",
+			course:        testCourse(testExamSequential("fx", nil, testVertical("v1", testProblem()), testVertical("v2", testProblem()))),
+			wantQuestions: []string{"v1", "v2"},
+			wantCount:     2,
+		},
+		{
+			name:          "a slot with several problems is a pool",
+			course:        testCourse(testExamSequential("fx", nil, testVertical("v1", testProblem(), testProblem()), testVertical("v2", testProblem()))),
+			wantQuestions: []string{"v2"},
+			wantPools:     []esmodels.ExamPool{{QuestionIDs: []string{"v1", "v1_1"}, Draw: 1}},
+			wantCount:     2,
+		},
+		{
+			name: "a tag pool draws out of the verticals of the exam with the tag",
+			course: testCourse(testExamSequential("fx", loops,
+				testVertical("v1", testProblem()),
+				testVertical("v2", testProblem("loops")),
+				testVertical("v3", testProblem("loops"), testProblem("loops")),
+			)),
+			wantQuestions: []string{"v1"},
+			wantPools:     []esmodels.ExamPool{{QuestionIDs: []string{"v2", "v3", "v3_1"}, Draw: 2, Tag: "loops"}},
+			wantCount:     3,
+		},
+		{
+			name: "a tag pool that draws all of its questions is fixed",
+			course: testCourse(testExamSequential("fx", loops,
+				testVertical("v1", testProblem()),
+				testVertical("v2", testProblem("loops")),
+				testVertical("v3", testProblem("loops")),
+			)),
+			wantQuestions: []string{"v1", "v2", "v3"},
+			wantCount:     3,
+		},
+		{
+			name: "a vertical with an untagged problem stays a slot",
+			course: testCourse(testExamSequential("fx", []ExamPoolRule{{Tag: "loops", Draw: 1}},
+				testVertical("v1", testProblem("loops"), testProblem()),
+				testVertical("v2", testProblem("loops")),
+				testVertical("v3", testProblem("loops")),
+			)),
+			wantPools: []esmodels.ExamPool{
+				{QuestionIDs: []string{"v1", "v1_1"}, Draw: 1},
+				{QuestionIDs: []string{"v2", "v3"}, Draw: 1, Tag: "loops"},
+			},
+			wantCount: 2,
+		},
+		{
+			name: "a tag pool with fewer questions than it draws",
+			course: testCourse(testExamSequential("fx", []ExamPoolRule{{Tag: "loops", Draw: 3}},
+				testVertical("v1", testProblem("loops")),
+				testVertical("v2", testProblem("loops")),
+			)),
+			wantErr: `the pool of tag "loops" has 2 questions that are not slots or in an earlier pool, fewer than the 3 to draw`,
+		},
+		{
+			name: "a tag pool does not take the questions of cards or of another exam",
+			course: testCourse(
+				&Sequential{URLName: "s", DisplayName: "Section", Verticals: []*Vertical{testVertical("c1", testProblem("loops"), testProblem("loops"))}},
+				testExamSequential("fx", loops, testVertical("v1", testProblem("loops")), testVertical("v2", testProblem("loops")), testVertical("v3", testProblem("loops"))),
+				testExamSequential("fx2", []ExamPoolRule{{Tag: "loops", Draw: 1}}, testVertical("w1", testProblem())),
+			),
+			wantErr: `sequential fx2: the pool of tag "loops" has 0 questions`,
+		},
+		{
+			name: "two pools of the same tag do not share questions",
+			course: testCourse(testExamSequential("fx", []ExamPoolRule{{Tag: "loops", Draw: 1}, {Tag: "loops", Draw: 1}},
+				testVertical("v1", testProblem("loops")),
+				testVertical("v2", testProblem("loops")),
+			)),
+			wantErr: `the pool of tag "loops" has 0 questions`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, exams, _, _, _, err := extractESFeatures(tt.course)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			exam := exams[0]
+			if !reflect.DeepEqual(exam.QuestionIDs, tt.wantQuestions) {
+				t.Errorf("got the fixed questions %v, want %v", exam.QuestionIDs, tt.wantQuestions)
+			}
+			if !reflect.DeepEqual(exam.Pools, tt.wantPools) {
+				t.Errorf("got the pools %+v, want %+v", exam.Pools, tt.wantPools)
+			}
+			if exam.QuestionCount != tt.wantCount {
+				t.Errorf("got %d questions per learner, want %d", exam.QuestionCount, tt.wantCount)
+			}
+			// Every learner gets the fixed questions and the draws of the pools, and no question is in both
+			count := len(exam.QuestionIDs)
+			fixed := map[string]bool{}
+			for _, id := range exam.QuestionIDs {
+				fixed[id] = true
+			}
+			for _, pool := range exam.Pools {
+				count += pool.Draw
+				for _, id := range pool.QuestionIDs {
+					if fixed[id] {
+						t.Errorf("question %s is both fixed and in a pool", id)
+					}
+				}
+			}
+			if count != exam.QuestionCount {
+				t.Errorf("got %d questions per learner, but the fixed questions and draws add up to %d", exam.QuestionCount, count)
+			}
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestValidateExamConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		seq     *Sequential
+		wantErr string
+	}{
+		{
+			name: "no exam settings",
+			seq:  &Sequential{URLName: "fx"},
+		},
+		{
+			name: "valid settings",
+			seq:  testExamSequential("fx", []ExamPoolRule{{Tag: "loops", Draw: 2}}),
+		},
+		{
+			name:    "settings of a sequential that is not a final exam",
+			seq:     &Sequential{URLName: "fx", Exam: &ExamConfig{}},
+			wantErr: `exam settings are only valid for a graded sequential with a "Final Exam" format`,
+		},
+		{
+			name:    "pass mark out of range",
+			seq:     &Sequential{URLName: "fx", Graded: true, Format: "Final Exam", Exam: &ExamConfig{PassMarkPct: floatPtr(0)}},
+			wantErr: "pass_mark_pct must be more than 0 and at most 100, got 0",
+		},
+		{
+			name:    "negative time limit",
+			seq:     &Sequential{URLName: "fx", Graded: true, Format: "Final Exam", Exam: &ExamConfig{TimeLimit: -5}},
+			wantErr: "time_limit must be a number of minutes, got -5",
+		},
+		{
+			name:    "weight out of range",
+			seq:     &Sequential{URLName: "fx", Graded: true, Format: "Final Exam", Exam: &ExamConfig{WeightPct: floatPtr(120)}},
+			wantErr: "weight_pct must be between 0 and 100, got 120",
+		},
+		{
+			name:    "empty tag",
+			seq:     &Sequential{URLName: "fx", Graded: true, Format: "Final Exam", Exam: &ExamConfig{Tags: []string{"loops", " "}}},
+			wantErr: "tags must not be empty",
+		},
+		{
+			name:    "all the problems of the pools are reported",
+			seq:     testExamSequential("fx", []ExamPoolRule{{Tag: "", Draw: 1}, {Tag: "loops", Draw: 0}}),
+			wantErr: "invalid exam settings in index.yaml of sequential fx: pool 1 must have a tag; pool 2 must draw 1 or more questions, got 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.seq.validateExamConfig()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFinalExamWeights(t *testing.T) {
+	// chapter returns a chapter with a final exam of the given weight, or none if the weight is negative
+	chapter := func(weights ...float64) *Chapter {
+		chap := &Chapter{URLName: "chapter"}
+		for i, weight := range weights {
+			seq := testExamSequential(fmt.Sprintf("fx%d", i), nil)
+			if weight >= 0 {
+				seq.Exam.WeightPct = floatPtr(weight)
+			}
+			chap.Sequentials = append(chap.Sequentials, seq)
+		}
+		return chap
+	}
+	tests := []struct {
+		name     string
+		chapters []*Chapter
+		want     []float64
+		wantErr  string
+	}{
+		{
+			name:     "chapters without a weight split the course equally",
+			chapters: []*Chapter{chapter(-1), chapter(-1), chapter(-1), chapter(-1)},
+			want:     []float64{25, 25, 25, 25},
+		},
+		{
+			name:     "chapters without a weight split what is left",
+			chapters: []*Chapter{chapter(50), chapter(-1), chapter(-1)},
+			want:     []float64{50, 25, 25},
+		},
+		{
+			name:     "a chapter without a final exam gets a share too",
+			chapters: []*Chapter{chapter(40), chapter()},
+			want:     []float64{40, 60},
+		},
+		{
+			name:     "weights that add up to 100 with rounding",
+			chapters: []*Chapter{chapter(33.33), chapter(33.33), chapter(33.34), chapter(-1)},
+			want:     []float64{33.33, 33.33, 33.34, 0},
+		},
+		{
+			name:     "the final exams of a chapter with the same weight",
+			chapters: []*Chapter{chapter(30, 30), chapter(-1)},
+			want:     []float64{30, 70},
+		},
+		{
+			name:     "the final exams of a chapter with different weights",
+			chapters: []*Chapter{chapter(30, 40)},
+			wantErr:  "weight_pct 40 differs from the 30 of index.yaml of sequential fx0",
+		},
+		{
+			name:     "weights that add up to more than 100",
+			chapters: []*Chapter{chapter(60), chapter(50)},
+			wantErr:  "the weight_pct values add up to 110, which is more than 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := finalExamWeights(&Course{Chapters: tt.chapters})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got the weights %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 0.0001 {
+					t.Errorf("got the weights %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
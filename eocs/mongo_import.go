@@ -0,0 +1,518 @@
+package eocs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/exlskills/eocsutil/wsenv"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mongoQuestion is the 'question' record as written by upsertCourseRecursive, with the data left raw since its shape
+// depends on the question type
+type mongoQuestion struct {
+	ID           string                       `bson:"_id"`
+	Data         bson.Raw                     `bson:"data"`
+	QuestionType string                       `bson:"question_type"`
+	Points       float64                      `bson:"points"`
+	ComplLevel   int                          `bson:"compl_level"`
+	Tags         []string                     `bson:"tags"`
+	QuestionText esmodels.IntlStringWrapper   `bson:"question_text"`
+	Hint         esmodels.IntlStringWrapper   `bson:"hint"`
+	Hints        []esmodels.IntlStringWrapper `bson:"hints"`
+}
+
+type mongoImportCtx struct {
+	db        *mgo.Database
+	questions map[string]*mongoQuestion
+}
+
+// resolveCourseFromMongo rebuilds the course with the given ID out of the 'course', 'versioned_content', 'question' and
+// 'exam' records. Cards become verticals with a single markdown block for their latest content, followed by one problem
+// block per question, and the final exams of a unit become graded sequentials at the end of its chapter
+func resolveCourseFromMongo(mongoURI, dbName, courseID string) (*Course, error) {
+	sess, err := mgo.DialWithTimeout(mongoURI, time.Duration(10*time.Second))
+	if err != nil {
+		Log.Error("MongoDB error", err)
+		return nil, err
+	}
+	defer sess.Close()
+	icx := &mongoImportCtx{
+		db:        sess.DB(dbName),
+		questions: map[string]*mongoQuestion{},
+	}
+
+	esc := esmodels.Course{}
+	err = icx.db.C("course").FindId(courseID).One(&esc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.New(fmt.Sprintf("eocs: course %s does not exist in database %s", courseID, dbName))
+	} else if err != nil {
+		Log.Errorf("MongoDB error reading course %s: %s", courseID, err.Error())
+		return nil, err
+	}
+
+	var qs []*mongoQuestion
+	err = icx.db.C("question").Find(bson.M{"course_item_ref.course_id": courseID}).All(&qs)
+	if err != nil {
+		Log.Errorf("MongoDB error listing the questions of course %s: %s", courseID, err.Error())
+		return nil, err
+	}
+	for _, q := range qs {
+		icx.questions[q.ID] = q
+	}
+
+	title := esc.Title.Default()
+	course := &Course{
+		URLName:           esc.ID,
+		DisplayName:       title.Content,
+		CourseImage:       esc.LogoURL,
+		Language:          title.Locale,
+		Headline:          esc.Headline.Default().Content,
+		Description:       esc.Description.Default().Content,
+		Topics:            esc.Topics,
+		PrimaryTopic:      esc.PrimaryTopic,
+		SkillLevel:        strconv.Itoa(esc.SkillLevel),
+		InfoMD:            esc.InfoMD.Default().Content,
+		RepoURL:           esc.RepoURL,
+		Weight:            esc.Weight,
+		EstMinutes:        esc.EstMinutes,
+		InstructorTimekit: esc.InstructorTimekit,
+		ContentUpdatedAt:  esc.ContentUpdatedAt,
+		ContentCreatedAt:  esc.CreatedAt,
+	}
+	if !esc.IsPublished {
+		course.IsPublished = &esc.IsPublished
+	}
+	if esc.SubscriptionLevel != defaultSubscriptionLevel {
+		course.SubscriptionLevel = esc.SubscriptionLevel
+	}
+	if esc.VerifiedCertCost != defaultVerifiedCertCost {
+		course.VerifiedCertCost = &esc.VerifiedCertCost
+	}
+	course.IsOrganizationOnly = esc.IsOrganizationOnly
+	if len(esc.OrganizationIDs) > 0 {
+		course.OrganizationIDs = esc.OrganizationIDs
+	}
+
+	units := esc.Units.Units
+	sort.SliceStable(units, func(i, j int) bool { return units[i].Index < units[j].Index })
+	for idx, unit := range units {
+		chap, err := icx.unitToChapter(idx, unit)
+		if err != nil {
+			return nil, err
+		}
+		course.Chapters = append(course.Chapters, chap)
+	}
+	Log.Infof("Read course %s from MongoDB: %d chapters and %d questions", course.URLName, len(course.Chapters), len(qs))
+	return course, nil
+}
+
+func (icx *mongoImportCtx) unitToChapter(index int, unit esmodels.Unit) (*Chapter, error) {
+	chap := &Chapter{
+		Index:       index,
+		URLName:     unit.ID,
+		DisplayName: unit.Title.Default().Content,
+		UpdatedAt:   unit.UpdatedAt,
+		OutlineInfo: storedOutlineInfo(unit.Title, unit.Headline, unit.Description, unit.Tags),
+	}
+	sectMinutes := 0
+	sections := unit.Sections.Sections
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Index < sections[j].Index })
+	for _, sect := range sections {
+		seq := &Sequential{
+			URLName:     sect.ID,
+			DisplayName: sect.Title.Default().Content,
+			UpdatedAt:   sect.UpdatedAt,
+			OutlineInfo: storedOutlineInfo(sect.Title, sect.Headline, sect.Description, sect.Tags),
+		}
+		// Only an estimate that was set explicitly goes back to index.yaml, not the sum of the parts
+		cardMinutes := 0
+		cards := sect.Cards.Cards
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Index < cards[j].Index })
+		for _, card := range cards {
+			vert, err := icx.cardToVertical(card)
+			if err != nil {
+				return nil, err
+			}
+			seq.Verticals = append(seq.Verticals, vert)
+			cardMinutes += card.EstMinutes
+		}
+		if sect.EstMinutes != cardMinutes {
+			seq.EstMinutes = sect.EstMinutes
+		}
+		sectMinutes += sect.EstMinutes
+		chap.Sequentials = append(chap.Sequentials, seq)
+	}
+	if unit.EstMinutes != sectMinutes {
+		chap.EstMinutes = unit.EstMinutes
+	}
+	for idx, examID := range unit.FinalExamIDs {
+		seq, err := icx.examToSequential(idx, examID, unit)
+		if err != nil {
+			return nil, err
+		}
+		chap.Sequentials = append(chap.Sequentials, seq)
+	}
+	return chap, nil
+}
+
+func (icx *mongoImportCtx) cardToVertical(card esmodels.Card) (*Vertical, error) {
+	vert := &Vertical{
+		URLName:     card.ID,
+		DisplayName: card.Title.Default().Content,
+		UpdatedAt:   card.UpdatedAt,
+		OutlineInfo: storedOutlineInfo(card.Title, card.Headline, card.Description, card.Tags),
+	}
+	vert.EstMinutes = card.EstMinutes
+	if card.ContentID != "" {
+		vc := esmodels.VersionedContent{}
+		err := icx.db.C("versioned_content").FindId(card.ContentID).One(&vc)
+		if err != nil && err != mgo.ErrNotFound {
+			Log.Errorf("MongoDB error reading 'versioned_content' %s: %s", card.ContentID, err.Error())
+			return nil, err
+		}
+		if content, ok := latestVersionedContent(vc); ok {
+			// Embedded editors were rendered into the card content, so they come back as their HTML within the markdown
+			vert.Blocks = append(vert.Blocks, &Block{
+				BlockType:   "html",
+				URLName:     blockURLName(vert.URLName, "Content", len(vert.Blocks)),
+				DisplayName: "Content",
+				Markdown:    strings.TrimSpace(content) + "\n",
+			})
+		} else {
+			Log.Warnf("Card %s points at content %s, which does not exist", card.ID, card.ContentID)
+		}
+	}
+	for _, qID := range card.QuestionIDs {
+		blk, err := icx.questionToBlock(qID, vert.URLName, len(vert.Blocks))
+		if err != nil {
+			return nil, err
+		}
+		vert.Blocks = append(vert.Blocks, blk)
+	}
+	return vert, nil
+}
+
+// examToSequential turns a final exam into the graded sequential that extractESExamFeatures reads it from, with one
+// vertical per slot holding the problems drawn for it and one per candidate of the pools drawn by tag
+func (icx *mongoImportCtx) examToSequential(index int, examID string, unit esmodels.Unit) (*Sequential, error) {
+	exam := esmodels.Exam{}
+	err := icx.db.C("exam").FindId(examID).One(&exam)
+	if err != nil {
+		Log.Errorf("MongoDB error reading 'exam' %s: %s", examID, err.Error())
+		return nil, err
+	}
+	seq := &Sequential{
+		URLName:     strings.TrimSuffix(exam.ID, "_exam"),
+		DisplayName: "Final Exam",
+		Graded:      true,
+		Format:      "Final Exam",
+		UpdatedAt:   exam.UpdatedAt,
+		Exam: &ExamConfig{
+			PassMarkPct:    &exam.PassMarkPct,
+			CreatorID:      exam.CreatorID,
+			TimeLimit:      exam.TimeLimit,
+			RandomOrder:    exam.RandomOrder,
+			UseIDETestMode: &exam.UseIDETestMode,
+			AttemptsPerDay: unit.AttemptsAllowedPerDay,
+			WeightPct:      &unit.FinalExamWeightPct,
+			Tags:           exam.Tags,
+		},
+	}
+	if index > 0 {
+		seq.DisplayName = fmt.Sprintf("Final Exam %d", index+1)
+	}
+	// Each fixed question has a slot of its own, the pools without a tag are slots with a choice
+	slots := make([][]string, 0, len(exam.QuestionIDs)+len(exam.Pools))
+	for _, qID := range exam.QuestionIDs {
+		slots = append(slots, []string{qID})
+	}
+	// The candidates of the pools drawn by tag get a vertical each, which has the tag and so is not a slot
+	var tagged [][]string
+	for _, pool := range exam.Pools {
+		if pool.Tag != "" {
+			seq.Exam.Pools = append(seq.Exam.Pools, ExamPoolRule{Tag: pool.Tag, Draw: pool.Draw})
+			for _, qID := range pool.QuestionIDs {
+				tagged = append(tagged, []string{qID})
+			}
+		} else if len(pool.QuestionIDs) > 0 {
+			slots = append(slots, pool.QuestionIDs)
+		}
+	}
+	slots = append(slots, tagged...)
+	for slotIdx, qIDs := range slots {
+		vert := &Vertical{
+			URLName:     qIDs[0],
+			DisplayName: fmt.Sprintf("Question %d", slotIdx+1),
+			UpdatedAt:   exam.UpdatedAt,
+		}
+		for position, qID := range qIDs {
+			blk, err := icx.questionToBlock(qID, vert.URLName, position)
+			if err != nil {
+				return nil, err
+			}
+			vert.Blocks = append(vert.Blocks, blk)
+		}
+		seq.Verticals = append(seq.Verticals, vert)
+	}
+	return seq, nil
+}
+
+func latestVersionedContent(vc esmodels.VersionedContent) (string, bool) {
+	latest := latestContentEntry(&vc)
+	if latest == nil {
+		return "", false
+	}
+	return latest.Content.Default().Content, true
+}
+
+// questionToBlock regenerates the .prob.md of a question. Code questions also get their REPL back, which is exported
+// next to the problem
+func (icx *mongoImportCtx) questionToBlock(qID, vertURLName string, position int) (*Block, error) {
+	q, ok := icx.questions[qID]
+	if !ok {
+		q = &mongoQuestion{}
+		err := icx.db.C("question").FindId(qID).One(q)
+		if err == mgo.ErrNotFound {
+			return nil, errors.New(fmt.Sprintf("eocs: question %s does not exist", qID))
+		} else if err != nil {
+			Log.Errorf("MongoDB error reading 'question' %s: %s", qID, err.Error())
+			return nil, err
+		}
+	}
+	md, rpl, err := q.toProblemMD()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("eocs: unable to rebuild question %s: %s", qID, err.Error()))
+	}
+	return &Block{
+		BlockType:   "problem",
+		URLName:     blockURLName(vertURLName, "Question", position),
+		DisplayName: "Question",
+		Markdown:    md,
+		REPL:        rpl,
+		Meta:        q.problemMeta(),
+	}, nil
+}
+
+// problemMeta returns the front matter for the points, complexity and tags of the question that are not the defaults
+func (q *mongoQuestion) problemMeta() *ProblemMeta {
+	meta := &ProblemMeta{Tags: q.Tags}
+	if q.Points != 1 {
+		meta.Points = &q.Points
+	}
+	if q.ComplLevel > 1 {
+		meta.Complexity = q.ComplLevel
+	}
+	if meta.Points == nil && meta.Complexity == 0 && len(meta.Tags) == 0 {
+		return nil
+	}
+	return meta
+}
+
+func (q *mongoQuestion) toProblemMD() (string, *BlockREPL, error) {
+	var rpl *BlockREPL
+	buf := &strings.Builder{}
+	buf.WriteString(">>" + q.QuestionText.Default().Content + "<<\n\n")
+	switch q.QuestionType {
+	case "MCSA", "MCMA", "DDSA":
+		var choices []esmodels.AnswerChoice
+		err := q.Data.Unmarshal(&choices)
+		if err != nil {
+			return "", nil, err
+		}
+		sort.SliceStable(choices, func(i, j int) bool { return choices[i].Sequence < choices[j].Sequence })
+		if q.QuestionType == "MCSA" {
+			writeMDChoices(buf, choices)
+		} else if q.QuestionType == "MCMA" {
+			writeMDCheckboxes(buf, choices)
+		} else {
+			writeMDDropdown(buf, choices)
+		}
+	case "NUMQ":
+		nqd := esmodels.NumericalQuestionData{}
+		err := q.Data.Unmarshal(&nqd)
+		if err != nil {
+			return "", nil, err
+		}
+		writeMDNumericalAnswer(buf, nqd)
+	case "WSCQ":
+		cqd := esmodels.CodeQuestionData{}
+		err := q.Data.Unmarshal(&cqd)
+		if err != nil {
+			return "", nil, err
+		}
+		rpl, err = codeQuestionDataToREPL(cqd)
+		if err != nil {
+			return "", nil, err
+		}
+		// exportBlock points the shebang at the REPL config once the file name is known
+		buf.WriteString("= #!exl::repl('Question.prob.repl.yaml')\n")
+	default:
+		return "", nil, errors.New(fmt.Sprintf("unsupported question type %s", q.QuestionType))
+	}
+	hints := q.Hints
+	if len(hints) == 0 {
+		// Questions loaded before all of the hints were kept only have the single one
+		hints = []esmodels.IntlStringWrapper{q.Hint}
+	}
+	hintsWritten := false
+	for _, h := range hints {
+		if hint := h.Default().Content; strings.TrimSpace(hint) != "" {
+			if !hintsWritten {
+				buf.WriteString("\n")
+				hintsWritten = true
+			}
+			buf.WriteString("||" + singleLineMD(hint) + "||\n")
+		}
+	}
+	return buf.String(), rpl, nil
+}
+
+// singleLineMD keeps hints on a single line, which is all the problem markdown hint syntax allows
+func singleLineMD(md string) string {
+	return strings.Join(strings.Fields(md), " ")
+}
+
+func mdHintSuffix(hint string) string {
+	if strings.TrimSpace(hint) == "" {
+		return ""
+	}
+	return " {{" + singleLineMD(hint) + "}}"
+}
+
+func writeMDChoices(buf *strings.Builder, choices []esmodels.AnswerChoice) {
+	for _, c := range choices {
+		mark := " "
+		if c.IsAnswer {
+			mark = "x"
+		}
+		text := strings.TrimSpace(c.Text.Default().Content)
+		if strings.Contains(text, "\n") {
+			// Code snippets and other multi-line choices need the +( ) ... -( )- syntax. The text starts on its own line
+			// so that a leading code fence is recognized
+			buf.WriteString(fmt.Sprintf("+(%s)\n%s\n", mark, text))
+			if hint := mdHintSuffix(c.Explanation.Default().Content); hint != "" {
+				buf.WriteString(strings.TrimSpace(hint) + "\n")
+			}
+			buf.WriteString(fmt.Sprintf("-(%s)-\n", mark))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("(%s) %s%s\n", mark, text, mdHintSuffix(c.Explanation.Default().Content)))
+	}
+}
+
+func writeMDCheckboxes(buf *strings.Builder, choices []esmodels.AnswerChoice) {
+	for _, c := range choices {
+		mark := " "
+		if c.IsAnswer {
+			mark = "x"
+		}
+		var hints []string
+		if expl := strings.TrimSpace(c.Explanation.Default().Content); expl != "" {
+			hints = append(hints, "{selected: "+singleLineMD(expl)+"}")
+		}
+		if expl := strings.TrimSpace(c.UnselectedExplanation.Default().Content); expl != "" {
+			hints = append(hints, "{unselected: "+singleLineMD(expl)+"}")
+		}
+		hint := ""
+		if len(hints) > 0 {
+			hint = " {{ " + strings.Join(hints, ", ") + " }}"
+		}
+		buf.WriteString(fmt.Sprintf("[%s] %s%s\n", mark, singleLineMD(c.Text.Default().Content), hint))
+	}
+}
+
+func writeMDDropdown(buf *strings.Builder, choices []esmodels.AnswerChoice) {
+	buf.WriteString("[[\n")
+	for _, c := range choices {
+		text := singleLineMD(c.Text.Default().Content)
+		if c.IsAnswer {
+			text = "(" + text + ")"
+		}
+		buf.WriteString(text + mdHintSuffix(c.Explanation.Default().Content) + "\n")
+	}
+	buf.WriteString("]]\n")
+}
+
+func writeMDNumericalAnswer(buf *strings.Builder, nqd esmodels.NumericalQuestionData) {
+	answer := nqd.Answer
+	if nqd.Tolerance != "" {
+		answer += " +- " + nqd.Tolerance
+	}
+	buf.WriteString("= " + answer + mdHintSuffix(nqd.Explanation.Default().Content) + "\n")
+	for _, aa := range nqd.AdditionalAnswers {
+		buf.WriteString("or= " + aa + "\n")
+	}
+}
+
+// codeQuestionDataToREPL is the reverse of olxStrRespToESQCodeData
+func codeQuestionDataToREPL(cqd esmodels.CodeQuestionData) (*BlockREPL, error) {
+	rpl := &BlockREPL{
+		APIVersion:     cqd.APIVersion,
+		EnvironmentKey: cqd.EnvironmentKey,
+		Display: &BlockREPLDisplay{
+			Height: "500px",
+		},
+		Explanation: stripCodeQuestionExplanationIFrame(cqd.Explanation.Default().Content),
+	}
+	if cqd.GradingStrategy != "default" {
+		rpl.GradingStrategy = cqd.GradingStrategy
+	}
+	var err error
+	rpl.SrcFiles, err = unmarshalWorkspaceFiles(cqd.SrcFiles.Default().Content)
+	if err != nil {
+		return nil, err
+	}
+	rpl.TmplFiles, err = unmarshalWorkspaceFiles(cqd.TmplFiles.Default().Content)
+	if err != nil {
+		return nil, err
+	}
+	rpl.TestFiles, err = unmarshalWorkspaceFiles(cqd.TestFiles)
+	if err != nil {
+		return nil, err
+	}
+	if cqd.GradingTests != "" {
+		err = json.Unmarshal([]byte(cqd.GradingTests), &rpl.Tests)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rpl, nil
+}
+
+func unmarshalWorkspaceFiles(filesJSON string) (files map[string]*wsenv.WorkspaceFile, err error) {
+	if filesJSON == "" {
+		return nil, nil
+	}
+	err = json.Unmarshal([]byte(filesJSON), &files)
+	return files, err
+}
+
+// stripCodeQuestionExplanationIFrame removes the embedded REPL that generateCodeQuestionExplanationMD appends
+func stripCodeQuestionExplanationIFrame(explanation string) string {
+	if idx := strings.LastIndex(explanation, "<iframe"); idx >= 0 {
+		explanation = explanation[:idx]
+	}
+	return strings.TrimSpace(explanation)
+}
+
+// storedOutlineInfo is the reverse of how the unit, section and card records get their headline, description and tags.
+// The default headline is left out
+func storedOutlineInfo(title, headline, description esmodels.IntlStringWrapper, tags []string) OutlineInfo {
+	info := OutlineInfo{
+		Description: description.Default().Content,
+	}
+	if h := headline.Default().Content; h != "Learn "+title.Default().Content {
+		info.Headline = h
+	}
+	if len(tags) > 0 {
+		info.Tags = tags
+	}
+	return info
+}
@@ -0,0 +1,93 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/diagnostics"
+	"path/filepath"
+	"sort"
+)
+
+// outlineItem is a chapter, sequential or vertical directory, for ordering it among its siblings
+type outlineItem struct {
+	dir     string
+	isDraft bool
+}
+
+// orderOutline sorts the chapters of the course, the sequentials of each chapter and the verticals of each sequential
+// by the numeric prefixes of their directories rather than by their names, the drafts coming after the published ones.
+// The prefixes of the directories of a folder must count up from 0 or 1 without repeats or gaps, all of those that do
+// not are reported
+func orderOutline(course *Course) (problems []diagnostics.Diagnostic) {
+	chapItems := make([]outlineItem, 0, len(course.Chapters))
+	for _, chap := range course.Chapters {
+		chapItems = append(chapItems, outlineItem{dir: chap.dir, isDraft: chap.IsDraft})
+	}
+	chaps := make([]*Chapter, 0, len(course.Chapters))
+	for _, i := range orderByPrefix(chapItems, &problems) {
+		chap := course.Chapters[i]
+		chap.Index = len(chaps)
+		chaps = append(chaps, chap)
+
+		seqItems := make([]outlineItem, 0, len(chap.Sequentials))
+		for _, seq := range chap.Sequentials {
+			seqItems = append(seqItems, outlineItem{dir: seq.dir, isDraft: seq.IsDraft})
+		}
+		seqs := make([]*Sequential, 0, len(chap.Sequentials))
+		for _, j := range orderByPrefix(seqItems, &problems) {
+			seq := chap.Sequentials[j]
+			seqs = append(seqs, seq)
+
+			vertItems := make([]outlineItem, 0, len(seq.Verticals))
+			for _, vert := range seq.Verticals {
+				vertItems = append(vertItems, outlineItem{dir: vert.dir, isDraft: vert.IsDraft})
+			}
+			verts := make([]*Vertical, 0, len(seq.Verticals))
+			for _, k := range orderByPrefix(vertItems, &problems) {
+				verts = append(verts, seq.Verticals[k])
+			}
+			seq.Verticals = verts
+		}
+		chap.Sequentials = seqs
+	}
+	course.Chapters = chaps
+	return problems
+}
+
+// orderByPrefix returns the positions of the items in the order of their prefixes, the drafts after the published ones,
+// adding the repeated and skipped prefixes of each of the two to problems
+func orderByPrefix(items []outlineItem, problems *[]diagnostics.Diagnostic) []int {
+	prefixes := make([]int, len(items))
+	order := make([]int, len(items))
+	for i, item := range items {
+		// The walk has already checked the names
+		prefixes[i], _, _ = indexAndNameFromConcatenated(filepath.Base(item.dir))
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if items[order[a]].isDraft != items[order[b]].isDraft {
+			return !items[order[a]].isDraft
+		}
+		return prefixes[order[a]] < prefixes[order[b]]
+	})
+	for n, i := range order {
+		dir := filepath.ToSlash(items[i].dir)
+		if n == 0 || items[order[n-1]].isDraft != items[i].isDraft {
+			if prefixes[i] > 1 {
+				*problems = append(*problems, orderDiagnostic(dir, "the first prefix is %d instead of 0 or 1", prefixes[i]))
+			}
+			continue
+		}
+		prev := order[n-1]
+		if prefixes[i] == prefixes[prev] {
+			*problems = append(*problems, orderDiagnostic(dir, "the prefix %d is also used by %s", prefixes[i], filepath.ToSlash(items[prev].dir)))
+		} else if prefixes[i] > prefixes[prev]+1 {
+			*problems = append(*problems, orderDiagnostic(dir, "the prefix %d follows %d, skipping %d", prefixes[i], prefixes[prev], prefixes[prev]+1))
+		}
+	}
+	return order
+}
+
+func orderDiagnostic(dir, format string, args ...interface{}) diagnostics.Diagnostic {
+	d := diagnostics.Errorf(dir, 0, ruleOutlineOrder, format, args...)
+	d.Message += ", which the renumber command fixes"
+	return d
+}
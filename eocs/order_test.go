@@ -0,0 +1,119 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/diagnostics"
+	"reflect"
+	"testing"
+)
+
+func TestOrderByPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  []outlineItem
+		want   []string
+		wantMs []string
+	}{
+		{
+			name:  "numeric rather than by name",
+			items: []outlineItem{{dir: "c/10_Maps"}, {dir: "c/2_Loops"}, {dir: "c/1_Intro"}, {dir: "c/3_Funcs"}, {dir: "c/4_a"}, {dir: "c/5_b"}, {dir: "c/6_c"}, {dir: "c/7_d"}, {dir: "c/8_e"}, {dir: "c/9_f"}},
+			want:  []string{"c/1_Intro", "c/2_Loops", "c/3_Funcs", "c/4_a", "c/5_b", "c/6_c", "c/7_d", "c/8_e", "c/9_f", "c/10_Maps"},
+		},
+		{
+			name:  "from 0",
+			items: []outlineItem{{dir: "c/01_Loops"}, {dir: "c/00_Intro"}},
+			want:  []string{"c/00_Intro", "c/01_Loops"},
+		},
+		{
+			name:  "drafts come after the published items and count on their own",
+			items: []outlineItem{{dir: "c/drafts/00_Draft", isDraft: true}, {dir: "c/01_Loops"}, {dir: "c/00_Intro"}, {dir: "c/drafts/01_Other", isDraft: true}},
+			want:  []string{"c/00_Intro", "c/01_Loops", "c/drafts/00_Draft", "c/drafts/01_Other"},
+		},
+		{
+			name:   "the first prefix is too high",
+			items:  []outlineItem{{dir: "c/02_Intro"}, {dir: "c/03_Loops"}},
+			want:   []string{"c/02_Intro", "c/03_Loops"},
+			wantMs: []string{"c/02_Intro: the first prefix is 2 instead of 0 or 1, which the renumber command fixes"},
+		},
+		{
+			name:   "a gap",
+			items:  []outlineItem{{dir: "c/00_Intro"}, {dir: "c/03_Maps"}, {dir: "c/01_Loops"}},
+			want:   []string{"c/00_Intro", "c/01_Loops", "c/03_Maps"},
+			wantMs: []string{"c/03_Maps: the prefix 3 follows 1, skipping 2, which the renumber command fixes"},
+		},
+		{
+			name:   "a repeated prefix keeps the order of the walk",
+			items:  []outlineItem{{dir: "c/00_Intro"}, {dir: "c/01_Loops"}, {dir: "c/01_Maps"}},
+			want:   []string{"c/00_Intro", "c/01_Loops", "c/01_Maps"},
+			wantMs: []string{"c/01_Maps: the prefix 1 is also used by c/01_Loops, which the renumber command fixes"},
+		},
+		{
+			name:   "the problems of the drafts are reported on their own",
+			items:  []outlineItem{{dir: "c/00_Intro"}, {dir: "c/drafts/02_Draft", isDraft: true}, {dir: "c/drafts/04_Other", isDraft: true}},
+			want:   []string{"c/00_Intro", "c/drafts/02_Draft", "c/drafts/04_Other"},
+			wantMs: []string{"c/drafts/02_Draft: the first prefix is 2 instead of 0 or 1, which the renumber command fixes", "c/drafts/04_Other: the prefix 4 follows 2, skipping 3, which the renumber command fixes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags []diagnostics.Diagnostic
+			order := orderByPrefix(tt.items, &diags)
+			var got []string
+			for _, i := range order {
+				got = append(got, tt.items[i].dir)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got the order %v, want %v", got, tt.want)
+			}
+			var gotMs []string
+			for _, d := range diags {
+				if d.Rule != ruleOutlineOrder {
+					t.Errorf("got the rule %s, want %s", d.Rule, ruleOutlineOrder)
+				}
+				gotMs = append(gotMs, d.Path+": "+d.Message)
+			}
+			if !reflect.DeepEqual(gotMs, tt.wantMs) {
+				t.Errorf("got the problems %q, want %q", gotMs, tt.wantMs)
+			}
+		})
+	}
+}
+
+func TestOrderOutline(t *testing.T) {
+	vert := func(dir string) *Vertical {
+		return &Vertical{URLName: dir, dir: dir}
+	}
+	course := &Course{Chapters: []*Chapter{
+		{URLName: "ch2", dir: "10_Maps", Sequentials: []*Sequential{
+			{URLName: "seq", dir: "10_Maps/00_Basics", Verticals: []*Vertical{vert("10_Maps/00_Basics/3_b"), vert("10_Maps/00_Basics/1_a")}},
+		}},
+		{URLName: "ch1", dir: "9_Loops", Sequentials: []*Sequential{
+			{URLName: "seq2", dir: "9_Loops/01_More"},
+			{URLName: "seq1", dir: "9_Loops/00_Basics"},
+		}},
+	}}
+	diags := orderOutline(course)
+	var got []string
+	for _, chap := range course.Chapters {
+		got = append(got, chap.URLName)
+		for _, seq := range chap.Sequentials {
+			got = append(got, seq.URLName)
+			for _, vert := range seq.Verticals {
+				got = append(got, vert.URLName)
+			}
+		}
+	}
+	want := []string{"ch1", "seq1", "seq2", "ch2", "seq", "10_Maps/00_Basics/1_a", "10_Maps/00_Basics/3_b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got the outline %v, want %v", got, want)
+	}
+	if course.Chapters[0].Index != 0 || course.Chapters[1].Index != 1 {
+		t.Errorf("got the chapter indexes %d and %d, want 0 and 1", course.Chapters[0].Index, course.Chapters[1].Index)
+	}
+	wantDiags := []diagnostics.Diagnostic{
+		orderDiagnostic("9_Loops", "the first prefix is %d instead of 0 or 1", 9),
+		orderDiagnostic("10_Maps/00_Basics/3_b", "the prefix %d follows %d, skipping %d", 3, 1, 2),
+	}
+	if !reflect.DeepEqual(diags, wantDiags) {
+		t.Errorf("got the problems %v, want %v", diags, wantDiags)
+	}
+}
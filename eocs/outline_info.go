@@ -0,0 +1,85 @@
+package eocs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OutlineInfo is what the index.yaml of a chapter, sequential or vertical may tell about the unit, section or card that
+// it becomes, beyond its name. All of it is optional
+type OutlineInfo struct {
+	Headline    string   `yaml:"headline,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,flow,omitempty"`
+	EstMinutes  int      `yaml:"est_minutes,omitempty"`
+}
+
+// headlineOr returns the headline, or "Learn <display name>" if there is none
+func (info *OutlineInfo) headlineOr(displayName string) string {
+	if info.Headline != "" {
+		return info.Headline
+	}
+	return "Learn " + displayName
+}
+
+func (info *OutlineInfo) tagsOrEmpty() []string {
+	if info.Tags == nil {
+		return []string{}
+	}
+	return info.Tags
+}
+
+// validate checks the settings read from the index.yaml in dir
+func (info *OutlineInfo) validate(dir string) error {
+	var problems []string
+	if info.EstMinutes < 0 {
+		problems = append(problems, fmt.Sprintf("est_minutes must not be negative, got %d", info.EstMinutes))
+	}
+	for _, tag := range info.Tags {
+		if strings.TrimSpace(tag) == "" || strings.Contains(tag, ",") {
+			problems = append(problems, fmt.Sprintf("tags must not be empty or contain commas, got %q", tag))
+			break
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(fmt.Sprintf("invalid settings in %s: %s", filepath.Join(dir, "index.yaml"), strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+func (info *OutlineInfo) extraAttributes() map[string]string {
+	attrs := map[string]string{
+		"headline":    info.Headline,
+		"description": info.Description,
+		"tags":        concatExtraAttrCSV(info.Tags),
+		"est_minutes": formatOptionalInt(info.EstMinutes),
+	}
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs
+}
+
+// outlineInfoFromExtraAttributes is the reverse of extraAttributes
+func outlineInfoFromExtraAttributes(attrs map[string]string) (OutlineInfo, error) {
+	info := OutlineInfo{
+		Headline:    attrs["headline"],
+		Description: attrs["description"],
+	}
+	if attrs["tags"] != "" {
+		info.Tags = extraAttrCSVToStrSlice(attrs["tags"])
+	}
+	if attrs["est_minutes"] != "" {
+		estMinutes, err := strconv.Atoi(attrs["est_minutes"])
+		if err != nil {
+			return info, errors.New(fmt.Sprintf("invalid est_minutes value: %s", attrs["est_minutes"]))
+		}
+		info.EstMinutes = estMinutes
+	}
+	return info, nil
+}
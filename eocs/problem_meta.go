@@ -0,0 +1,175 @@
+package eocs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/diagnostics"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"gopkg.in/yaml.v2"
+	"strconv"
+	"strings"
+)
+
+const frontMatterDelimiter = "---"
+
+// ProblemMeta is the optional YAML front matter of a .prob.md file, between two `---` lines at the very top. The body
+// that follows is the problem markdown
+type ProblemMeta struct {
+	Points     *float64 `yaml:"points,omitempty"`
+	Complexity int      `yaml:"complexity,omitempty"`
+	Tags       []string `yaml:"tags,flow,omitempty"`
+	EstTimeSec int      `yaml:"est_time_sec,omitempty"`
+	// Explanation is used where the problem does not explain itself: for the correct choices without a hint, for a
+	// numerical answer without a hint and for a code question whose REPL has no explanation
+	Explanation string `yaml:"explanation,omitempty"`
+}
+
+// splitProblemFrontMatter separates the front matter from the problem markdown, returning a nil meta if there is none
+func splitProblemFrontMatter(md string) (*ProblemMeta, string, error) {
+	lines := strings.SplitAfter(md, "\n")
+	if len(lines) == 0 || strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff")) != frontMatterDelimiter {
+		return nil, md, nil
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != frontMatterDelimiter {
+			continue
+		}
+		meta := &ProblemMeta{}
+		err := yaml.UnmarshalStrict([]byte(strings.Join(lines[1:i], "")), meta)
+		if err != nil {
+			// The front matter starts on the second line of the file
+			line := diagnostics.LineFromYAMLError(err)
+			if line > 0 {
+				line++
+			}
+			return nil, "", &fileError{line: line, err: errors.New(fmt.Sprintf("invalid front matter: %s", err.Error()))}
+		}
+		err = meta.validate()
+		if err != nil {
+			return nil, "", err
+		}
+		return meta, strings.TrimLeft(strings.Join(lines[i+1:], ""), "\n"), nil
+	}
+	return nil, "", errors.New("invalid front matter: the closing --- line is missing")
+}
+
+func (meta *ProblemMeta) validate() error {
+	var problems []string
+	if meta.Points != nil && *meta.Points < 0 {
+		problems = append(problems, fmt.Sprintf("points must not be negative, got %v", *meta.Points))
+	}
+	if meta.Complexity < 0 {
+		problems = append(problems, fmt.Sprintf("complexity must be 1 or more, got %d", meta.Complexity))
+	}
+	if meta.EstTimeSec < 0 {
+		problems = append(problems, fmt.Sprintf("est_time_sec must not be negative, got %d", meta.EstTimeSec))
+	}
+	for _, tag := range meta.Tags {
+		if strings.TrimSpace(tag) == "" {
+			problems = append(problems, "tags must not be empty")
+			break
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid front matter: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// withFrontMatter puts the front matter back on top of the problem markdown
+func (meta *ProblemMeta) withFrontMatter(md string) (string, error) {
+	if meta == nil {
+		return md, nil
+	}
+	b, err := yaml.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	return frontMatterDelimiter + "\n" + string(b) + frontMatterDelimiter + "\n\n" + md, nil
+}
+
+// applyTo overrides the defaults of the question with the front matter, except for the explanation, which is filled in
+// by extractEQQuestionFromBlock
+func (meta *ProblemMeta) applyTo(q *esmodels.Question, lang string) {
+	if meta.Points != nil {
+		q.Points = *meta.Points
+	}
+	if meta.Complexity != 0 {
+		q.ComplexityLevel = meta.Complexity
+	}
+	if len(meta.Tags) > 0 {
+		q.Tags = meta.Tags
+	}
+	if meta.EstTimeSec != 0 {
+		q.EstTimeSec = meta.EstTimeSec
+	}
+	if meta.Explanation == "" {
+		return
+	}
+	switch data := q.Data.(type) {
+	case []esmodels.AnswerChoice:
+		for i := range data {
+			if data[i].IsAnswer && strings.TrimSpace(data[i].Explanation.Default().Content) == "" {
+				data[i].Explanation = esmodels.NewIntlStringWrapper(meta.Explanation, lang)
+			}
+		}
+	case esmodels.NumericalQuestionData:
+		if strings.TrimSpace(data.Explanation.Default().Content) == "" {
+			data.Explanation = esmodels.NewIntlStringWrapper(meta.Explanation, lang)
+			q.Data = data
+		}
+	}
+}
+
+// extraAttributes carries the front matter over to other formats
+func (meta *ProblemMeta) extraAttributes() map[string]string {
+	attrs := map[string]string{
+		"points":       formatOptionalFloat(meta.Points),
+		"complexity":   formatOptionalInt(meta.Complexity),
+		"tags":         concatExtraAttrCSV(meta.Tags),
+		"est_time_sec": formatOptionalInt(meta.EstTimeSec),
+		"explanation":  meta.Explanation,
+	}
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs
+}
+
+// problemMetaFromExtraAttributes is the reverse of extraAttributes, returning nil if there is no front matter
+func problemMetaFromExtraAttributes(attrs map[string]string) (*ProblemMeta, error) {
+	meta := &ProblemMeta{}
+	found := false
+	for k, v := range attrs {
+		if v == "" {
+			continue
+		}
+		var err error
+		switch k {
+		case "points":
+			var f float64
+			f, err = strconv.ParseFloat(v, 64)
+			meta.Points = &f
+		case "complexity":
+			meta.Complexity, err = strconv.Atoi(v)
+		case "tags":
+			meta.Tags = extraAttrCSVToStrSlice(v)
+		case "est_time_sec":
+			meta.EstTimeSec, err = strconv.Atoi(v)
+		case "explanation":
+			meta.Explanation = v
+		default:
+			continue
+		}
+		found = true
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s value: %s", k, v))
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return meta, meta.validate()
+}
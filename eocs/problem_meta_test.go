@@ -0,0 +1,208 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitProblemFrontMatter(t *testing.T) {
+	points := 2.5
+	tests := []struct {
+		name     string
+		md       string
+		wantMeta *ProblemMeta
+		wantBody string
+		wantErr  string
+		wantLine int
+	}{
+		{
+			name:     "no front matter",
+			md:       ">>Which one?<<\n\n(x) This one\n",
+			wantBody: ">>Which one?<<\n\n(x) This one\n",
+		},
+		{
+			name:     "a horizontal rule further down is not front matter",
+			md:       ">>Which one?<<\n---\n(x) This one\n",
+			wantBody: ">>Which one?<<\n---\n(x) This one\n",
+		},
+		{
+			name:     "front matter",
+			md:       "---\npoints: 2.5\ncomplexity: 3\ntags: [loops, maps]\nest_time_sec: 90\nexplanation: Because\n---\n\n>>Which one?<<\n",
+			wantMeta: &ProblemMeta{Points: &points, Complexity: 3, Tags: []string{"loops", "maps"}, EstTimeSec: 90, Explanation: "Because"},
+			wantBody: ">>Which one?<<\n",
+		},
+		{
+			name:     "front matter after a byte order mark",
+			md:       "\ufeff---\ncomplexity: 2\n---\n>>Which one?<<\n",
+			wantMeta: &ProblemMeta{Complexity: 2},
+			wantBody: ">>Which one?<<\n",
+		},
+		{
+			name:     "an unknown field",
+			md:       "---\ncomplexity: 2\npoint: 1\n---\n>>Which one?<<\n",
+			wantErr:  "invalid front matter",
+			wantLine: 3,
+		},
+		{
+			name:    "the closing line is missing",
+			md:      "---\ncomplexity: 2\n>>Which one?<<\n",
+			wantErr: "invalid front matter: the closing --- line is missing",
+		},
+		{
+			name:    "invalid values",
+			md:      "---\npoints: -1\ncomplexity: -2\ntags: [loops, \"\"]\n---\n>>Which one?<<\n",
+			wantErr: "invalid front matter: points must not be negative, got -1; complexity must be 1 or more, got -2; tags must not be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := splitProblemFrontMatter(tt.md)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				if line := errorLine(err); line != tt.wantLine {
+					t.Errorf("got the error on line %d, want %d", line, tt.wantLine)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(meta, tt.wantMeta) {
+				t.Errorf("got the meta %+v, want %+v", meta, tt.wantMeta)
+			}
+			if body != tt.wantBody {
+				t.Errorf("got the body %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestProblemMetaRoundTrip(t *testing.T) {
+	points := 0.0
+	tests := []struct {
+		name string
+		meta *ProblemMeta
+	}{
+		{
+			name: "all the fields",
+			meta: &ProblemMeta{Points: &points, Complexity: 3, Tags: []string{"loops", "maps"}, EstTimeSec: 90, Explanation: "Because"},
+		},
+		{
+			name: "some of the fields",
+			meta: &ProblemMeta{Tags: []string{"loops"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := tt.meta.withFrontMatter(">>Which one?<<\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta, body, err := splitProblemFrontMatter(md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(meta, tt.meta) || body != ">>Which one?<<\n" {
+				t.Errorf("got %+v and %q back from the front matter, want %+v", meta, body, tt.meta)
+			}
+			meta, err = problemMetaFromExtraAttributes(tt.meta.extraAttributes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(meta, tt.meta) {
+				t.Errorf("got %+v back from the extra attributes, want %+v", meta, tt.meta)
+			}
+		})
+	}
+}
+
+func TestProblemMetaFromExtraAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]string
+		wantMeta *ProblemMeta
+		wantErr  string
+	}{
+		{
+			name:  "no front matter attributes",
+			attrs: map[string]string{"display_name": "Check", "points": ""},
+		},
+		{
+			name:     "other attributes are ignored",
+			attrs:    map[string]string{"display_name": "Check", "complexity": "2"},
+			wantMeta: &ProblemMeta{Complexity: 2},
+		},
+		{
+			name:    "a value that is not a number",
+			attrs:   map[string]string{"est_time_sec": "a minute"},
+			wantErr: "invalid est_time_sec value: a minute",
+		},
+		{
+			name:    "a value that is out of range",
+			attrs:   map[string]string{"complexity": "-1"},
+			wantErr: "complexity must be 1 or more, got -1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := problemMetaFromExtraAttributes(tt.attrs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(meta, tt.wantMeta) {
+				t.Errorf("got %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestProblemMetaApplyTo(t *testing.T) {
+	points := 4.0
+	meta := &ProblemMeta{Points: &points, Complexity: 3, Tags: []string{"loops"}, EstTimeSec: 90, Explanation: "Because"}
+	tests := []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{
+			name: "correct choices without a hint get the explanation",
+			data: []esmodels.AnswerChoice{
+				{IsAnswer: true},
+				{IsAnswer: true, Explanation: esmodels.NewIntlStringWrapper("Its own", "en")},
+				{IsAnswer: false},
+			},
+			want: []esmodels.AnswerChoice{
+				{IsAnswer: true, Explanation: esmodels.NewIntlStringWrapper("Because", "en")},
+				{IsAnswer: true, Explanation: esmodels.NewIntlStringWrapper("Its own", "en")},
+				{IsAnswer: false},
+			},
+		},
+		{
+			name: "a numerical answer without a hint gets the explanation",
+			data: esmodels.NumericalQuestionData{Answer: "8"},
+			want: esmodels.NumericalQuestionData{Answer: "8", Explanation: esmodels.NewIntlStringWrapper("Because", "en")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &esmodels.Question{Points: 1, ComplexityLevel: 1, EstTimeSec: 60, Data: tt.data}
+			meta.applyTo(q, "en")
+			if q.Points != 4 || q.ComplexityLevel != 3 || !reflect.DeepEqual(q.Tags, []string{"loops"}) || q.EstTimeSec != 90 {
+				t.Errorf("got the points %v, complexity %d, tags %v and time %d, want the ones of the front matter", q.Points, q.ComplexityLevel, q.Tags, q.EstTimeSec)
+			}
+			if !reflect.DeepEqual(q.Data, tt.want) {
+				t.Errorf("got the data %+v, want %+v", q.Data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"sort"
+)
+
+// findStoredCourseRecords lists the records and search docs that the store holds for the course, by kind. It has to be
+// called before the push overwrites the course record, which is where the card contents and final exams of the course
+// are found: the questions are the ones that reference the course, and the search docs are those of the course in the
+// index of each language that the course is or was in
+func findStoredCourseRecords(store courseStore, course *Course, elasticsearchIndex string, plan *pushPlan) (map[string][]string, error) {
+	stored := map[string][]string{}
+	esc := esmodels.Course{}
+	_, err := store.FindRecord(pushKindCourse, plan.courseID, &esc)
+	if err != nil {
+		return nil, err
+	}
+	qIDs, err := store.FindCourseQuestionIDs(plan.courseID)
+	if err != nil {
+		return nil, err
+	}
+	stored[pushKindQuestion] = qIDs
+	for _, unit := range esc.Units.Units {
+		stored[pushKindExam] = append(stored[pushKindExam], unit.FinalExamIDs...)
+		for _, sect := range unit.Sections.Sections {
+			for _, card := range sect.Cards.Cards {
+				if card.ContentID != "" {
+					stored[pushKindVersionedContent] = append(stored[pushKindVersionedContent], card.ContentID)
+				}
+				stored[pushKindQuestion] = append(stored[pushKindQuestion], card.QuestionIDs...)
+			}
+		}
+	}
+	if !store.HasSearchIndex() {
+		return stored, nil
+	}
+	kinds := map[string]bool{searchDocKind(course, course.GetLanguage()): true}
+	for _, locale := range course.TranslationLocales {
+		kinds[searchDocKind(course, locale)] = true
+	}
+	// The languages of the stored course may have been dropped since
+	for _, s := range esc.Title.Strings {
+		kinds[searchDocKind(course, s.Locale)] = true
+	}
+	for _, kind := range plan.searchDocKinds() {
+		kinds[kind] = true
+	}
+	for kind := range kinds {
+		ids, err := store.FindCourseSearchDocIDs(searchDocAlias(course, elasticsearchIndex, kind), plan.courseID)
+		if err != nil {
+			return nil, err
+		}
+		stored[kind] = ids
+	}
+	return stored, nil
+}
+
+// pruneOrphanedRecords finds the records of the course that were not produced by this push: the ones found in the store
+// by findStoredCourseRecords, plus the ones recorded by the previous push state. They are only reported unless prune is
+// set, in which case they are deleted. Orphaned search docs can only be handled when the store has a search index, they
+// are removed from the index of their language
+func pruneOrphanedRecords(store courseStore, course *Course, elasticsearchIndex string, plan *pushPlan, stored map[string][]string, prune bool) error {
+	kinds := []string{pushKindExam, pushKindQuestion, pushKindVersionedContent}
+	if store.HasSearchIndex() {
+		kinds = append(kinds, storedSearchDocKinds(plan, stored)...)
+	}
+	for _, kind := range kinds {
+		ids := findOrphans(plan, kind, stored[kind])
+		if len(ids) == 0 {
+			continue
+		}
+		if !prune {
+			Log.Warnf("%d orphaned '%s' records are no longer part of course %s, use --prune to remove them: %v", len(ids), kind, plan.courseID, ids)
+			continue
+		}
+		for _, id := range ids {
+			var err error
+			if isSearchDocKind(kind) {
+				err = store.RemoveSearchDoc(searchDocAlias(course, elasticsearchIndex, kind), id)
+			} else {
+				err = store.RemoveRecord(kind, id)
+			}
+			if err != nil {
+				return err
+			}
+			plan.forget(kind, id)
+		}
+		Log.Infof("Pruned %d orphaned '%s' records from course %s: %v", len(ids), kind, plan.courseID, ids)
+	}
+	return nil
+}
+
+// storedSearchDocKinds returns the search doc kinds of the push along with those found in the store, sorted
+func storedSearchDocKinds(plan *pushPlan, stored map[string][]string) []string {
+	found := map[string]bool{}
+	for _, kind := range plan.searchDocKinds() {
+		found[kind] = true
+	}
+	for kind := range stored {
+		if isSearchDocKind(kind) {
+			found[kind] = true
+		}
+	}
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// findOrphans returns the stored IDs of the kind that this push did not produce, along with the orphans of the previous
+// push state
+func findOrphans(plan *pushPlan, kind string, stored []string) []string {
+	orphaned := map[string]bool{}
+	for _, id := range stored {
+		if _, produced := plan.newHashes[pushStateKey(kind, id)]; !produced {
+			orphaned[id] = true
+		}
+	}
+	for _, id := range plan.orphans(kind) {
+		orphaned[id] = true
+	}
+	ids := make([]string, 0, len(orphaned))
+	for id := range orphaned {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
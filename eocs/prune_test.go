@@ -0,0 +1,124 @@
+package eocs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindOrphans(t *testing.T) {
+	tests := []struct {
+		name string
+		// prev and pushed are the question IDs of the previous push state and of this push
+		prev   []string
+		pushed []string
+		stored []string
+		want   []string
+	}{
+		{
+			name:   "nothing is orphaned",
+			prev:   []string{"q1", "q2"},
+			pushed: []string{"q1", "q2"},
+			stored: []string{"q1", "q2"},
+			want:   []string{},
+		},
+		{
+			name:   "a stored record that was not pushed",
+			pushed: []string{"q1"},
+			stored: []string{"q1", "q2"},
+			want:   []string{"q2"},
+		},
+		{
+			name:   "a record of the previous push state that is not in the store",
+			prev:   []string{"q1", "q2"},
+			pushed: []string{"q1"},
+			stored: []string{"q1"},
+			want:   []string{"q2"},
+		},
+		{
+			name:   "the orphans of the store and of the push state are merged",
+			prev:   []string{"q1", "q3"},
+			pushed: []string{"q1"},
+			stored: []string{"q3", "q2", "q1"},
+			want:   []string{"q2", "q3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &pushPlan{prevHashes: map[string]string{}, newHashes: map[string]string{}}
+			for _, id := range tt.prev {
+				plan.prevHashes[pushStateKey(pushKindQuestion, id)] = "h"
+			}
+			for _, id := range tt.pushed {
+				plan.newHashes[pushStateKey(pushKindQuestion, id)] = "h"
+			}
+			// A search doc of the same ID must not count
+			plan.prevHashes[pushStateKey(pushKindSearchDoc, "q9")] = "h"
+			got := findOrphans(plan, pushKindQuestion, tt.stored)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPruneOrphanedRecords pushes the testdata course to a file+json store and then pushes it without one of its cards.
+// The records of the card are only removed with prune
+func TestPruneOrphanedRecords(t *testing.T) {
+	orphans := []string{
+		filepath.Join(pushKindQuestion, "check_q_0.json"),
+		filepath.Join(pushKindVersionedContent, "check_vc.json"),
+		filepath.Join("search", "learn_en", "Q2FyZDpjaGVjaw==.json"),
+	}
+	tests := []struct {
+		name       string
+		prune      bool
+		wantPruned bool
+	}{
+		{name: "orphans are only reported"},
+		{name: "orphans are removed with prune", prune: true, wantPruned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir, err := ioutil.TempDir("", "eocs-prune")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(outDir)
+			push := func(dropCard bool, opts pushOptions) {
+				course, err := resolveCourseRecursive(filepath.Join("testdata", "golden_course"), true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				stampGoldenTimes(course)
+				if dropCard {
+					seq := course.Chapters[0].Sequentials[0]
+					seq.Verticals = seq.Verticals[:1]
+				}
+				store, err := newCourseStore(jsonFileStoreURIPrefix + outDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer store.Close()
+				err = upsertCourseRecursive(course, store, "learn", opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			push(false, pushOptions{})
+			push(true, pushOptions{Prune: tt.prune})
+			for _, orphan := range orphans {
+				_, err := os.Stat(filepath.Join(outDir, orphan))
+				if pruned := os.IsNotExist(err); pruned != tt.wantPruned {
+					t.Errorf("got %s pruned %v, want %v", orphan, pruned, tt.wantPruned)
+				}
+			}
+			_, err = os.Stat(filepath.Join(outDir, pushKindVersionedContent, "declaring_vc.json"))
+			if err != nil {
+				t.Errorf("the content of a card of the course was removed: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package eocs
+
+import (
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/globalsign/mgo/bson"
+	"sort"
+	"strings"
+)
+
+// pushDiff is what a push of the course would change compared to the records that are in the store
+type pushDiff struct {
+	courseID    string
+	courseIsNew bool
+	units       diffList
+	sections    diffList
+	cards       diffList
+	questions   diffList
+	// searchDocs has a list per language, since each has its own index
+	searchDocs []*diffList
+}
+
+type diffList struct {
+	name    string
+	added   []string
+	removed []string
+	changed []string
+}
+
+// diffItem is a unit, section or card of the course outline
+type diffItem struct {
+	id        string
+	title     string
+	contentID string
+}
+
+func (item diffItem) String() string {
+	return fmt.Sprintf("%s %q", item.id, item.title)
+}
+
+// diffCourseAgainstStore runs the conversion of a push and compares its output to what is stored for the course
+func diffCourseAgainstStore(course *Course, store courseStore, elasticsearchIndex string) (*pushDiff, error) {
+	esc, _, qs, vcs, esearchdocs, err := convertToESCourse(course)
+	if err != nil {
+		return nil, err
+	}
+	d := &pushDiff{
+		courseID:  esc.ID,
+		units:     diffList{name: "Units"},
+		sections:  diffList{name: "Sections"},
+		cards:     diffList{name: "Cards"},
+		questions: diffList{name: "Questions"},
+	}
+
+	stored := esmodels.Course{}
+	found, err := store.FindRecord(pushKindCourse, esc.ID, &stored)
+	if err != nil {
+		return nil, err
+	}
+	d.courseIsNew = !found
+	oldUnits, oldSections, oldCards := flattenCourseOutline(stored.Units)
+	newUnits, newSections, newCards := flattenCourseOutline(esc.Units)
+	d.units.compareItems(oldUnits, newUnits)
+	d.sections.compareItems(oldSections, newSections)
+	commonCards := d.cards.compareItems(oldCards, newCards)
+
+	newContents := make(map[string]*esmodels.VersionedContent, len(vcs))
+	for _, vc := range vcs {
+		newContents[vc.ID] = vc
+	}
+	for _, pair := range commonCards {
+		oldVC := esmodels.VersionedContent{}
+		_, err := store.FindRecord(pushKindVersionedContent, pair[0].contentID, &oldVC)
+		if err != nil {
+			return nil, err
+		}
+		oldContent, _ := latestVersionedContent(oldVC)
+		newContent := ""
+		if vc, ok := newContents[pair[1].contentID]; ok {
+			newContent, _ = latestVersionedContent(*vc)
+		}
+		if oldContent != newContent {
+			d.cards.changed = append(d.cards.changed, fmt.Sprintf("%s: content changed, becomes version %d", pair[1], oldVC.LatestVersion+1))
+		}
+	}
+
+	err = d.compareQuestions(store, qs)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.HasSearchIndex() {
+		err = d.compareSearchDocs(course, store, elasticsearchIndex, esearchdocs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// compareSearchDocs compares the search docs with the ones of the course that are in the index of each language, which
+// are found the same way as for the prune of a push. Since the index docs are not read back, a stored doc counts as
+// changed when its hash differs from the one recorded by the previous push, or when no push recorded it
+func (d *pushDiff) compareSearchDocs(course *Course, store courseStore, elasticsearchIndex string, esearchdocs []*esmodels.ElasticsearchGenDoc) error {
+	plan, err := newPushPlan(store, d.courseID, true)
+	if err != nil {
+		return err
+	}
+	for _, esd := range esearchdocs {
+		kind := searchDocKind(course, searchDocLocale(course, esd))
+		_, err := plan.needsWrite(kind, esd.ID, esd)
+		if err != nil {
+			return err
+		}
+	}
+	stored, err := findStoredCourseRecords(store, course, elasticsearchIndex, plan)
+	if err != nil {
+		return err
+	}
+	for _, kind := range storedSearchDocKinds(plan, stored) {
+		l := &diffList{name: "Search docs (" + searchDocAlias(course, elasticsearchIndex, kind) + ")"}
+		inStore := make(map[string]bool, len(stored[kind]))
+		for _, id := range stored[kind] {
+			inStore[id] = true
+		}
+		for _, esd := range esearchdocs {
+			if searchDocKind(course, searchDocLocale(course, esd)) != kind {
+				continue
+			}
+			key := pushStateKey(kind, esd.ID)
+			desc := fmt.Sprintf("%s %s %q", esd.ID, esd.DocType, esd.Title)
+			if !inStore[esd.ID] {
+				l.added = append(l.added, desc)
+			} else if prevHash, recorded := plan.prevHashes[key]; !recorded || prevHash != plan.newHashes[key] {
+				l.changed = append(l.changed, desc)
+			}
+		}
+		l.removed = findOrphans(plan, kind, stored[kind])
+		d.searchDocs = append(d.searchDocs, l)
+	}
+	return nil
+}
+
+func flattenCourseOutline(uw esmodels.UnitsWrapper) (units, sections, cards []diffItem) {
+	for _, u := range uw.Units {
+		units = append(units, diffItem{id: u.ID, title: u.Title.Default().Content})
+		for _, s := range u.Sections.Sections {
+			sections = append(sections, diffItem{id: s.ID, title: s.Title.Default().Content})
+			for _, c := range s.Cards.Cards {
+				cards = append(cards, diffItem{id: c.ID, title: c.Title.Default().Content, contentID: c.ContentID})
+			}
+		}
+	}
+	return
+}
+
+// compareItems records the added, removed and renamed items, returning the (old, new) pairs of the items in both lists
+func (l *diffList) compareItems(oldItems, newItems []diffItem) (common [][2]diffItem) {
+	oldByID := make(map[string]diffItem, len(oldItems))
+	for _, item := range oldItems {
+		oldByID[item.id] = item
+	}
+	newIDs := make(map[string]bool, len(newItems))
+	for _, item := range newItems {
+		newIDs[item.id] = true
+		oldItem, ok := oldByID[item.id]
+		if !ok {
+			l.added = append(l.added, item.String())
+			continue
+		}
+		if oldItem.title != item.title {
+			l.changed = append(l.changed, fmt.Sprintf("%s: renamed from %q", item, oldItem.title))
+		}
+		common = append(common, [2]diffItem{oldItem, item})
+	}
+	for _, item := range oldItems {
+		if !newIDs[item.id] {
+			l.removed = append(l.removed, item.String())
+		}
+	}
+	return common
+}
+
+// compareQuestions compares the questions as stored, going through BSON on the converted side too so that both are in
+// the same form
+func (d *pushDiff) compareQuestions(store courseStore, qs []*esmodels.Question) error {
+	storedIDs, err := store.FindCourseQuestionIDs(d.courseID)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool, len(storedIDs))
+	for _, id := range storedIDs {
+		stored[id] = true
+	}
+	produced := make(map[string]bool, len(qs))
+	for _, q := range qs {
+		produced[q.ID] = true
+		if !stored[q.ID] {
+			d.questions.added = append(d.questions.added, fmt.Sprintf("%s %s", q.ID, q.QuestionType))
+			continue
+		}
+		oldDoc := bson.M{}
+		_, err := store.FindRecord(pushKindQuestion, q.ID, &oldDoc)
+		if err != nil {
+			return err
+		}
+		newDoc, err := recordToBSONDoc(q)
+		if err != nil {
+			return err
+		}
+		var changes []string
+		for _, field := range []struct{ key, desc string }{
+			{"question_type", "type"},
+			{"question_text", "text"},
+			{"data", "answers"},
+			{"hint", "hint"},
+			{"hints", "hints"},
+		} {
+			same, err := sameBSONValue(oldDoc[field.key], newDoc[field.key])
+			if err != nil {
+				return err
+			}
+			if !same {
+				changes = append(changes, field.desc)
+			}
+		}
+		if len(changes) > 0 {
+			d.questions.changed = append(d.questions.changed, fmt.Sprintf("%s %s: %s changed", q.ID, q.QuestionType, strings.Join(changes, ", ")))
+		}
+	}
+	sort.Strings(storedIDs)
+	for _, id := range storedIDs {
+		if !produced[id] {
+			d.questions.removed = append(d.questions.removed, id)
+		}
+	}
+	return nil
+}
+
+func sameBSONValue(a, b interface{}) (bool, error) {
+	aHash, err := contentHash(bsonToExtJSON(a))
+	if err != nil {
+		return false, err
+	}
+	bHash, err := contentHash(bsonToExtJSON(b))
+	if err != nil {
+		return false, err
+	}
+	return aHash == bHash, nil
+}
+
+func (l *diffList) writeTo(buf *strings.Builder) {
+	buf.WriteString(fmt.Sprintf("%s: %d added, %d removed, %d changed\n", l.name, len(l.added), len(l.removed), len(l.changed)))
+	for _, s := range l.added {
+		buf.WriteString("  + " + s + "\n")
+	}
+	for _, s := range l.removed {
+		buf.WriteString("  - " + s + "\n")
+	}
+	for _, s := range l.changed {
+		buf.WriteString("  ~ " + s + "\n")
+	}
+}
+
+func (d *pushDiff) String() string {
+	buf := &strings.Builder{}
+	buf.WriteString(fmt.Sprintf("Dry run of the push of course %s, nothing was written\n", d.courseID))
+	if d.courseIsNew {
+		buf.WriteString("The course does not exist yet, so all of it would be added\n")
+	}
+	for _, l := range append([]*diffList{&d.units, &d.sections, &d.cards, &d.questions}, d.searchDocs...) {
+		l.writeTo(buf)
+	}
+	return buf.String()
+}
@@ -0,0 +1,283 @@
+package eocs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record kinds tracked in the push state. The Mongo ones match the collection names
+const (
+	pushKindQuestion         = "question"
+	pushKindVersionedContent = "versioned_content"
+	pushKindExam             = "exam"
+	pushKindCourse           = "course"
+	pushKindSearchDoc        = "search_doc"
+)
+
+// hashIgnoredFields are the timestamps that are (re)generated on every conversion, so they must not count as changes.
+// The snake case names are the ones of the stored documents
+var hashIgnoredFields = map[string]bool{
+	"CreatedAt":              true,
+	"UpdatedAt":              true,
+	"StaticDataUpdatedAt":    true,
+	"created_at":             true,
+	"updated_at":             true,
+	"static_data_updated_at": true,
+}
+
+// pushOptions control how upsertCourseRecursive writes the converted course
+type pushOptions struct {
+	Incremental   bool
+	Prune         bool
+	ReindexSearch bool
+	// ContentVersionsKept is the number of card content versions retained in versioned_content, 0 keeps all of them
+	ContentVersionsKept int
+}
+
+type pushKindStats struct {
+	Inserted int
+	Updated  int
+	// Restored are the unchanged records that were written again since they were missing from the store
+	Restored  int
+	Unchanged int
+}
+
+// pushPlan compares the converted records to the hashes saved by the previous push, deciding what has to be written
+type pushPlan struct {
+	incremental bool
+	courseID    string
+	prevHashes  map[string]string
+	newHashes   map[string]string
+	// forgotten are the orphaned records that were removed, every other orphan is carried over to the next push state
+	forgotten map[string]bool
+	stats     map[string]*pushKindStats
+	// lookedUp are the kinds whose records were looked up in the store, stored are the records found by the lookups. The
+	// saved hash of a record of those kinds only counts if the record is still there, since it may have been removed or
+	// the database restored from a backup since the push that saved the hash
+	lookedUp map[string]bool
+	stored   map[string]bool
+}
+
+func newPushPlan(store courseStore, courseID string, incremental bool) (*pushPlan, error) {
+	pp := &pushPlan{
+		incremental: incremental,
+		courseID:    courseID,
+		prevHashes:  map[string]string{},
+		newHashes:   map[string]string{},
+		forgotten:   map[string]bool{},
+		stats:       map[string]*pushKindStats{},
+		lookedUp:    map[string]bool{},
+		stored:      map[string]bool{},
+	}
+	prev := esmodels.PushState{}
+	_, err := store.FindRecord(esmodels.PushStateCollection, courseID, &prev)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range prev.Records {
+		pp.prevHashes[pushStateKey(r.Kind, r.ID)] = r.Hash
+	}
+	return pp, nil
+}
+
+func pushStateKey(kind, id string) string {
+	return kind + "/" + id
+}
+
+// lookUpStoredRecords finds out which of the records of the collection are in the store. Only an incremental push
+// needs to know, since every record is written otherwise
+func (pp *pushPlan) lookUpStoredRecords(store courseStore, collection string, ids []string) error {
+	if !pp.incremental {
+		return nil
+	}
+	found, err := store.FindRecordIDs(collection, ids)
+	if err != nil {
+		return err
+	}
+	pp.markStored(collection, found)
+	return nil
+}
+
+// lookUpStoredSearchDocs finds out which of the search docs of the kind are in the index
+func (pp *pushPlan) lookUpStoredSearchDocs(store courseStore, kind, index string) error {
+	if !pp.incremental {
+		return nil
+	}
+	found, err := store.FindCourseSearchDocIDs(index, pp.courseID)
+	if err != nil {
+		return err
+	}
+	pp.markStored(kind, found)
+	return nil
+}
+
+func (pp *pushPlan) markStored(kind string, ids []string) {
+	pp.lookedUp[kind] = true
+	for _, id := range ids {
+		pp.stored[pushStateKey(kind, id)] = true
+	}
+}
+
+// needsWrite records the hash of the record and returns whether it has to be written. In non-incremental mode every
+// record is written, but the hashes are still recorded so that a later incremental push has something to compare to
+func (pp *pushPlan) needsWrite(kind, id string, record interface{}) (bool, error) {
+	hash, err := contentHash(record)
+	if err != nil {
+		return false, err
+	}
+	key := pushStateKey(kind, id)
+	pp.newHashes[key] = hash
+	st, ok := pp.stats[kind]
+	if !ok {
+		st = &pushKindStats{}
+		pp.stats[kind] = st
+	}
+	prevHash, existed := pp.prevHashes[key]
+	if !existed {
+		st.Inserted++
+		return true, nil
+	}
+	if prevHash != hash {
+		st.Updated++
+		return true, nil
+	}
+	if pp.lookedUp[kind] && !pp.stored[key] {
+		st.Restored++
+		return true, nil
+	}
+	st.Unchanged++
+	return !pp.incremental, nil
+}
+
+// orphans returns the IDs of the given kind that were recorded by the previous push but not by this one
+func (pp *pushPlan) orphans(kind string) []string {
+	var ids []string
+	for key := range pp.prevHashes {
+		if _, ok := pp.newHashes[key]; ok {
+			continue
+		}
+		if kindAndID := strings.SplitN(key, "/", 2); kindAndID[0] == kind {
+			ids = append(ids, kindAndID[1])
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// forget drops an orphaned record that was removed, so it is not carried over into the saved push state
+func (pp *pushPlan) forget(kind, id string) {
+	pp.forgotten[pushStateKey(kind, id)] = true
+}
+
+// save stores the hashes of this push, which only happens once everything has been written successfully
+func (pp *pushPlan) save(store courseStore) error {
+	ps := esmodels.PushState{
+		ID:        pp.courseID,
+		Records:   make([]esmodels.PushStateRecord, 0, len(pp.prevHashes)+len(pp.newHashes)),
+		UpdatedAt: time.Now(),
+	}
+	hashes := make(map[string]string, len(pp.newHashes))
+	for k, h := range pp.prevHashes {
+		if !pp.forgotten[k] {
+			hashes[k] = h
+		}
+	}
+	for k, h := range pp.newHashes {
+		hashes[k] = h
+	}
+	keys := make([]string, 0, len(hashes))
+	for k := range hashes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kindAndID := strings.SplitN(k, "/", 2)
+		ps.Records = append(ps.Records, esmodels.PushStateRecord{Kind: kindAndID[0], ID: kindAndID[1], Hash: hashes[k]})
+	}
+	return store.UpsertRecord(esmodels.PushStateCollection, ps.ID, ps)
+}
+
+// isSearchDocKind tells the search docs of the course language and of its translations apart from the records
+func isSearchDocKind(kind string) bool {
+	return kind == pushKindSearchDoc || strings.HasPrefix(kind, pushKindSearchDoc+"_")
+}
+
+// searchDocKinds returns the search doc kinds recorded by the previous push or by this one
+func (pp *pushPlan) searchDocKinds() []string {
+	found := map[string]bool{}
+	for _, hashes := range []map[string]string{pp.prevHashes, pp.newHashes} {
+		for key := range hashes {
+			if kind := strings.SplitN(key, "/", 2)[0]; isSearchDocKind(kind) {
+				found[kind] = true
+			}
+		}
+	}
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (pp *pushPlan) logSummary() {
+	mode := "Full"
+	if pp.incremental {
+		mode = "Incremental"
+	}
+	for _, kind := range append([]string{pushKindCourse, pushKindExam, pushKindQuestion, pushKindVersionedContent}, pp.searchDocKinds()...) {
+		st, ok := pp.stats[kind]
+		if !ok {
+			continue
+		}
+		Log.Infof("%s push summary for '%s': %s", mode, kind, st)
+	}
+}
+
+// contentHash hashes the JSON form of the record with the hashIgnoredFields removed. Going through a generic JSON value
+// also gives a stable key order for any maps in the record
+func contentHash(record interface{}) (string, error) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	err = json.Unmarshal(b, &generic)
+	if err != nil {
+		return "", err
+	}
+	b, err = json.Marshal(stripHashIgnoredFields(generic))
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func stripHashIgnoredFields(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for k, child := range tv {
+			if hashIgnoredFields[k] {
+				delete(tv, k)
+				continue
+			}
+			tv[k] = stripHashIgnoredFields(child)
+		}
+	case []interface{}:
+		for i := range tv {
+			tv[i] = stripHashIgnoredFields(tv[i])
+		}
+	}
+	return v
+}
+
+func (s pushKindStats) String() string {
+	return fmt.Sprintf("%d inserted, %d updated, %d restored, %d unchanged", s.Inserted, s.Updated, s.Restored, s.Unchanged)
+}
@@ -0,0 +1,267 @@
+package eocs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	type record struct {
+		Title     string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		Items     []map[string]interface{}
+	}
+	now := time.Now()
+	tests := []struct {
+		name      string
+		a, b      record
+		wantEqual bool
+	}{
+		{
+			name:      "the same record",
+			a:         record{Title: "a", CreatedAt: now},
+			b:         record{Title: "a", CreatedAt: now},
+			wantEqual: true,
+		},
+		{
+			name:      "only the times differ",
+			a:         record{Title: "a", CreatedAt: now, UpdatedAt: now},
+			b:         record{Title: "a", CreatedAt: now.Add(time.Hour), UpdatedAt: now.Add(2 * time.Hour)},
+			wantEqual: true,
+		},
+		{
+			name:      "only the times of a nested document differ",
+			a:         record{Title: "a", Items: []map[string]interface{}{{"text": "x", "created_at": now, "updated_at": now}}},
+			b:         record{Title: "a", Items: []map[string]interface{}{{"text": "x", "created_at": now.Add(time.Hour)}}},
+			wantEqual: true,
+		},
+		{
+			name: "the content differs",
+			a:    record{Title: "a", CreatedAt: now},
+			b:    record{Title: "b", CreatedAt: now},
+		},
+		{
+			name: "the content of a nested document differs",
+			a:    record{Title: "a", Items: []map[string]interface{}{{"text": "x"}}},
+			b:    record{Title: "a", Items: []map[string]interface{}{{"text": "y"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashA, err := contentHash(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hashB, err := contentHash(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (hashA == hashB) != tt.wantEqual {
+				t.Errorf("got the hashes %s and %s, want them equal: %v", hashA, hashB, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestPushPlanNeedsWrite(t *testing.T) {
+	record := map[string]string{"title": "a"}
+	hash, err := contentHash(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		incremental bool
+		// prevHash is the hash saved by the previous push, none if it is empty
+		prevHash  string
+		lookedUp  bool
+		stored    bool
+		wantWrite bool
+		wantStats pushKindStats
+	}{
+		{
+			name:      "a new record",
+			wantWrite: true,
+			wantStats: pushKindStats{Inserted: 1},
+		},
+		{
+			name:        "a new record in an incremental push",
+			incremental: true,
+			wantWrite:   true,
+			wantStats:   pushKindStats{Inserted: 1},
+		},
+		{
+			name:        "a changed record",
+			incremental: true,
+			prevHash:    "0123",
+			wantWrite:   true,
+			wantStats:   pushKindStats{Updated: 1},
+		},
+		{
+			name:      "an unchanged record is written in a full push",
+			prevHash:  hash,
+			wantWrite: true,
+			wantStats: pushKindStats{Unchanged: 1},
+		},
+		{
+			name:        "an unchanged record is skipped in an incremental push",
+			incremental: true,
+			prevHash:    hash,
+			wantStats:   pushKindStats{Unchanged: 1},
+		},
+		{
+			name:        "an unchanged record that is in the store is skipped",
+			incremental: true,
+			prevHash:    hash,
+			lookedUp:    true,
+			stored:      true,
+			wantStats:   pushKindStats{Unchanged: 1},
+		},
+		{
+			name:        "an unchanged record that is missing from the store is restored",
+			incremental: true,
+			prevHash:    hash,
+			lookedUp:    true,
+			wantWrite:   true,
+			wantStats:   pushKindStats{Restored: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &pushPlan{
+				incremental: tt.incremental,
+				courseID:    "course",
+				prevHashes:  map[string]string{},
+				newHashes:   map[string]string{},
+				forgotten:   map[string]bool{},
+				stats:       map[string]*pushKindStats{},
+				lookedUp:    map[string]bool{},
+				stored:      map[string]bool{},
+			}
+			if tt.prevHash != "" {
+				plan.prevHashes[pushStateKey(pushKindQuestion, "q1")] = tt.prevHash
+			}
+			if tt.lookedUp {
+				var ids []string
+				if tt.stored {
+					ids = append(ids, "q1")
+				}
+				plan.markStored(pushKindQuestion, ids)
+			}
+			write, err := plan.needsWrite(pushKindQuestion, "q1", record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if write != tt.wantWrite {
+				t.Errorf("got write %v, want %v", write, tt.wantWrite)
+			}
+			if got := *plan.stats[pushKindQuestion]; got != tt.wantStats {
+				t.Errorf("got the stats %s, want %s", got, tt.wantStats)
+			}
+			if got := plan.newHashes[pushStateKey(pushKindQuestion, "q1")]; got != hash {
+				t.Errorf("got the recorded hash %s, want %s", got, hash)
+			}
+		})
+	}
+}
+
+func TestPushPlanOrphans(t *testing.T) {
+	plan := &pushPlan{
+		prevHashes: map[string]string{
+			"question/q3":         "h",
+			"question/q1":         "h",
+			"question/q2":         "h",
+			"exam/e1":             "h",
+			"search_doc/Q2FyZDpj": "h",
+		},
+		newHashes: map[string]string{
+			"question/q2": "h",
+			"question/q4": "h",
+		},
+	}
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{kind: pushKindQuestion, want: []string{"q1", "q3"}},
+		{kind: pushKindExam, want: []string{"e1"}},
+		{kind: pushKindSearchDoc, want: []string{"Q2FyZDpj"}},
+		{kind: pushKindVersionedContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			got := plan.orphans(tt.kind)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIncrementalPush pushes the testdata course to a file+json store and then pushes it again incrementally, after
+// marking one stored record and removing another. Only the removed one may be written again
+func TestIncrementalPush(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "eocs-incremental")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+	push := func() {
+		course, err := resolveCourseRecursive(filepath.Join("testdata", "golden_course"), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stampGoldenTimes(course)
+		store, err := newCourseStore(jsonFileStoreURIPrefix + outDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		err = upsertCourseRecursive(course, store, "learn", pushOptions{Incremental: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	push()
+	marked := filepath.Join(outDir, pushKindQuestion, "check_q_0.json")
+	doc := map[string]interface{}{}
+	contents, err := ioutil.ReadFile(marked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(contents, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc["marked"] = true
+	contents, err = json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(marked, contents, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed := filepath.Join(outDir, pushKindVersionedContent, "check_vc.json")
+	err = os.Remove(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push()
+	got, err := ioutil.ReadFile(marked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(contents) {
+		t.Errorf("the unchanged question was written again")
+	}
+	if _, err := os.Stat(removed); err != nil {
+		t.Errorf("the removed versioned content was not restored: %s", err)
+	}
+}
@@ -0,0 +1,268 @@
+package eocs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	indexURLNameRegex      = regexp.MustCompile(`(?m)^url_name:(?:[ \t]*(?:""|''|~|null))?([ \t]+#[^\n]*)?[ \t]*$`)
+	yamlDocumentStartRegex = regexp.MustCompile(`\A---[ \t]*\n`)
+)
+
+// renumberCourse rewrites the numeric prefixes of the chapter, sequential and vertical directories of the course in
+// rootDir to a sequence from 00, keeping their order. The url_names are written into the index.yaml of the directories
+// that lack one beforehand, since the url_names assigned to them are derived from their paths
+func renumberCourse(rootDir string) error {
+	courseYAML, err := getIndexYAML(rootDir)
+	if err != nil {
+		return err
+	}
+	c := &Course{}
+	err = yaml.Unmarshal(courseYAML, c)
+	if err != nil {
+		return err
+	}
+	if c.URLName == "" {
+		return errors.New("eocs: the course index.yaml must have a url_name to renumber the course")
+	}
+	err = persistOutlineIDs(rootDir, rootDir, c.URLName, 1)
+	if err != nil {
+		return err
+	}
+	return renumberOutlineDirs(rootDir, rootDir, 1)
+}
+
+// outlineDirs lists the chapter, sequential or vertical directories in dir and in its drafts folder, each sorted by
+// their numeric prefixes and then by name
+func outlineDirs(dir string) (published, drafts []string, err error) {
+	published, err = listOutlineDirs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, draftsDirName)); err == nil {
+		drafts, err = listOutlineDirs(filepath.Join(dir, draftsDirName))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return published, drafts, nil
+}
+
+func listOutlineDirs(dir string) ([]string, error) {
+	listing, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	prefixes := make(map[string]int)
+	for _, fi := range listing {
+		if !fi.IsDir() || filepath.Ext(fi.Name()) == ".repl" || isIgnoredDir(fi.Name()) || fi.Name() == draftsDirName {
+			continue
+		}
+		prefix, _, err := indexAndNameFromConcatenated(fi.Name())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("%s: %s", filepath.Join(dir, fi.Name()), err.Error()))
+		}
+		prefixes[fi.Name()] = prefix
+		dirs = append(dirs, filepath.Join(dir, fi.Name()))
+	}
+	// The listing is sorted by name already
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return prefixes[filepath.Base(dirs[i])] < prefixes[filepath.Base(dirs[j])]
+	})
+	return dirs, nil
+}
+
+// persistOutlineIDs writes the url_name that the import assigns to each directory below dir that has none into its
+// index.yaml. depth is 1 for the chapters, 2 for the sequentials and 3 for the verticals
+func persistOutlineIDs(rootDir, dir, courseURLName string, depth int) error {
+	published, drafts, err := outlineDirs(dir)
+	if err != nil {
+		return err
+	}
+	for _, itemDir := range append(published, drafts...) {
+		relPath, err := filepath.Rel(rootDir, itemDir)
+		if err != nil {
+			return err
+		}
+		_, dispName, _ := indexAndNameFromConcatenated(filepath.Base(itemDir))
+		err = persistURLName(itemDir, esmodels.StableESID(courseURLName, filepath.ToSlash(relPath)), dispName)
+		if err != nil {
+			return err
+		}
+		if depth < 3 {
+			err = persistOutlineIDs(rootDir, itemDir, courseURLName, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// persistURLName adds the url_name to the index.yaml of dir unless it has one. Only the url_name line is written, so
+// the comments and the formatting of the rest of the file are kept. A missing index.yaml is written with the display
+// name that the import takes from the directory name
+func persistURLName(dir, urlName, dispName string) error {
+	path := existingIndexYAMLPath(dir)
+	if path == "" {
+		return writeIndexYAML(dir, yaml.MapSlice{{Key: "url_name", Value: urlName}, {Key: "display_name", Value: dispName}})
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var existing struct {
+		URLName string `yaml:"url_name"`
+	}
+	err = yaml.Unmarshal(contents, &existing)
+	if err != nil {
+		return errors.New(fmt.Sprintf("%s: %s", path, err.Error()))
+	}
+	if existing.URLName != "" {
+		return nil
+	}
+	line, err := yaml.Marshal(yaml.MapSlice{{Key: "url_name", Value: urlName}})
+	if err != nil {
+		return err
+	}
+	// An empty url_name is filled in, otherwise the line goes at the top of the document
+	var updated []byte
+	if loc := indexURLNameRegex.FindSubmatchIndex(contents); loc != nil {
+		updated = append(updated, contents[:loc[0]]...)
+		updated = append(updated, bytes.TrimSuffix(line, []byte("\n"))...)
+		if loc[2] >= 0 {
+			updated = append(updated, contents[loc[2]:loc[3]]...)
+		}
+		updated = append(updated, contents[loc[1]:]...)
+	} else {
+		start := 0
+		if loc := yamlDocumentStartRegex.FindIndex(contents); loc != nil {
+			start = loc[1]
+		}
+		updated = append(updated, contents[:start]...)
+		updated = append(updated, line...)
+		updated = append(updated, contents[start:]...)
+	}
+	return ioutil.WriteFile(path, updated, 0755)
+}
+
+// existingIndexYAMLPath returns the path of the index.yaml (or index.yml) of dir, or an empty string if it has none
+func existingIndexYAMLPath(dir string) string {
+	for _, name := range []string{"index.yaml", "index.yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// renumberOutlineDirs renames the directories below dir to a sequence of prefixes from 00, the drafts apart from the
+// published ones
+func renumberOutlineDirs(rootDir, dir string, depth int) error {
+	published, drafts, err := outlineDirs(dir)
+	if err != nil {
+		return err
+	}
+	for _, dirs := range [][]string{published, drafts} {
+		renamed, err := renumberSiblingDirs(rootDir, dirs)
+		if err != nil {
+			return err
+		}
+		if depth < 3 {
+			for _, itemDir := range renamed {
+				err = renumberOutlineDirs(rootDir, itemDir, depth+1)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// renumberSiblingDirs renames the directories, which are in the same folder, to their position in the list, returning
+// their new paths. They are moved aside first, so that no new name is taken by a directory that is yet to be renamed.
+// The names are all checked before anything is renamed, and the directories are moved back if a rename fails
+func renumberSiblingDirs(rootDir string, dirs []string) ([]string, error) {
+	width := len(strconv.Itoa(len(dirs) - 1))
+	if width < 2 {
+		width = 2
+	}
+	renaming := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		renaming[dir] = true
+	}
+	newDirs := make([]string, len(dirs))
+	for i, oldDir := range dirs {
+		_, name, _ := indexAndNameFromConcatenated(filepath.Base(oldDir))
+		newDirs[i] = filepath.Join(filepath.Dir(oldDir), fmt.Sprintf("%0*d_%s", width, i, name))
+		if newDirs[i] == oldDir {
+			continue
+		}
+		if _, err := os.Stat(newDirs[i]); err == nil && !renaming[newDirs[i]] {
+			return nil, errors.New(fmt.Sprintf("eocs: cannot rename %s, %s already exists", oldDir, newDirs[i]))
+		}
+		if _, err := os.Stat(tempRenumberDir(oldDir)); err == nil {
+			return nil, errors.New(fmt.Sprintf("eocs: cannot rename %s, %s is in the way", oldDir, tempRenumberDir(oldDir)))
+		}
+	}
+	var aside, renamed []int
+	rollBack := func(cause error) ([]string, error) {
+		for _, i := range renamed {
+			err := os.Rename(newDirs[i], tempRenumberDir(dirs[i]))
+			if err != nil {
+				Log.Errorf("Unable to move %s back to %s: %s", newDirs[i], dirs[i], err.Error())
+				continue
+			}
+			aside = append(aside, i)
+		}
+		for _, i := range aside {
+			err := os.Rename(tempRenumberDir(dirs[i]), dirs[i])
+			if err != nil {
+				Log.Errorf("Unable to move %s back to %s: %s", tempRenumberDir(dirs[i]), dirs[i], err.Error())
+			}
+		}
+		return nil, cause
+	}
+	for i, oldDir := range dirs {
+		if newDirs[i] == oldDir {
+			continue
+		}
+		err := os.Rename(oldDir, tempRenumberDir(oldDir))
+		if err != nil {
+			return rollBack(err)
+		}
+		aside = append(aside, i)
+	}
+	moved := aside
+	aside = nil
+	for n, i := range moved {
+		err := os.Rename(tempRenumberDir(dirs[i]), newDirs[i])
+		if err != nil {
+			aside = moved[n:]
+			return rollBack(err)
+		}
+		renamed = append(renamed, i)
+	}
+	for _, i := range renamed {
+		oldRelPath, _ := filepath.Rel(rootDir, dirs[i])
+		newRelPath, _ := filepath.Rel(rootDir, newDirs[i])
+		Log.Infof("Renamed %s to %s", oldRelPath, newRelPath)
+	}
+	return newDirs, nil
+}
+
+func tempRenumberDir(dir string) string {
+	return filepath.Join(filepath.Dir(dir), ".renumber"+filepath.Base(dir))
+}
@@ -0,0 +1,178 @@
+package eocs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPersistURLName(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		want     string
+	}{
+		{
+			name: "missing index.yaml",
+			file: "index.yaml",
+			want: "url_name: new_id\ndisplay_name: Loops\n",
+		},
+		{
+			name:     "comments and formatting are kept",
+			file:     "index.yaml",
+			contents: "# The loops chapter\ndisplay_name:   Loops   # shown in the outline\n\ntags: [a, b]\n",
+			want:     "url_name: new_id\n# The loops chapter\ndisplay_name:   Loops   # shown in the outline\n\ntags: [a, b]\n",
+		},
+		{
+			name:     "after the document start",
+			file:     "index.yaml",
+			contents: "---\ndisplay_name: Loops\n",
+			want:     "---\nurl_name: new_id\ndisplay_name: Loops\n",
+		},
+		{
+			name:     "an empty url_name is filled in",
+			file:     "index.yaml",
+			contents: "display_name: Loops\nurl_name: \"\" # set by renumber\ntags: []\n",
+			want:     "display_name: Loops\nurl_name: new_id # set by renumber\ntags: []\n",
+		},
+		{
+			name:     "an url_name without a value is filled in",
+			file:     "index.yaml",
+			contents: "url_name:\ndisplay_name: Loops\n",
+			want:     "url_name: new_id\ndisplay_name: Loops\n",
+		},
+		{
+			name:     "an existing url_name is left as it is",
+			file:     "index.yaml",
+			contents: "# Keep me\nurl_name: old_id\n",
+			want:     "# Keep me\nurl_name: old_id\n",
+		},
+		{
+			name:     "index.yml",
+			file:     "index.yml",
+			contents: "display_name: Loops\n",
+			want:     "url_name: new_id\ndisplay_name: Loops\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "eocs-renumber")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if tt.contents != "" {
+				err = ioutil.WriteFile(filepath.Join(dir, tt.file), []byte(tt.contents), 0755)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = persistURLName(dir, "new_id", "Loops")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenumberSiblingDirs(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		// other are directories of the folder that are not renumbered
+		other   []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "gaps are closed",
+			dirs: []string{"03_Intro", "07_Loops", "12_Maps"},
+			want: []string{"00_Intro", "01_Loops", "02_Maps"},
+		},
+		{
+			name: "a new name that is the old name of a sibling",
+			dirs: []string{"01_Loops", "03_Loops"},
+			want: []string{"00_Loops", "01_Loops"},
+		},
+		{
+			name: "the width grows with the number of directories",
+			dirs: []string{"01_a", "02_b", "03_c", "04_d", "05_e", "06_f", "07_g", "08_h", "09_i", "10_j", "11_k"},
+			want: []string{"00_a", "01_b", "02_c", "03_d", "04_e", "05_f", "06_g", "07_h", "08_i", "09_j", "10_k"},
+		},
+		{
+			name:    "a new name that is taken leaves all the directories as they are",
+			dirs:    []string{"03_Intro", "07_Loops"},
+			other:   []string{"01_Loops"},
+			want:    []string{"01_Loops", "03_Intro", "07_Loops"},
+			wantErr: "already exists",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "eocs-renumber")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			var dirs []string
+			for _, name := range append(append([]string{}, tt.dirs...), tt.other...) {
+				err = os.Mkdir(filepath.Join(root, name), 0755)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// A file in each directory tells where it went
+				err = ioutil.WriteFile(filepath.Join(root, name, "origin"), []byte(name), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.dirs {
+				dirs = append(dirs, filepath.Join(root, name))
+			}
+			_, err = renumberSiblingDirs(root, dirs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			listing, err := ioutil.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, fi := range listing {
+				got = append(got, fi.Name())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got the directories %v, want %v", got, tt.want)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			for i, name := range tt.want {
+				origin, err := ioutil.ReadFile(filepath.Join(root, name, "origin"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(origin) != tt.dirs[i] {
+					t.Errorf("got %s renamed to %s, want %s", origin, name, tt.dirs[i])
+				}
+			}
+		})
+	}
+}
@@ -21,7 +21,17 @@ func appendIRSequentialsToChapter(chap *Chapter, seqs []ir.Sequential) (err erro
 			DisplayName: s.GetDisplayName(),
 			Graded:      s.GetIsGraded(),
 			Format:      s.GetAssignmentType(),
+			IsDraft:     s.GetIsDraft(),
 		}
+		newS.Exam, err = examConfigFromExtraAttributes(s.GetExtraAttributes())
+		if err != nil {
+			return err
+		}
+		newS.OutlineInfo, err = outlineInfoFromExtraAttributes(s.GetExtraAttributes())
+		if err != nil {
+			return err
+		}
+		newS.Translated = translationsFromExtraAttributes(s.GetExtraAttributes())
 		err = appendIRVerticalsToSequential(newS, s.GetVerticals())
 		if err != nil {
 			return err
@@ -36,8 +46,16 @@ type Sequential struct {
 	DisplayName string      `yaml:"display_name"`
 	Graded      bool        `yaml:"graded"`
 	Format      string      `yaml:"format"`
+	Exam        *ExamConfig `yaml:"exam,omitempty"`
 	Verticals   []*Vertical `yaml:"-"`
 	UpdatedAt   time.Time   `yaml:"-"`
+	CreatedAt   time.Time   `yaml:"-"`
+	OutlineInfo `yaml:",inline"`
+	Translated  map[string]*Translation `yaml:"-"`
+	// dir is the directory of the sequential relative to the course, for naming its index.yaml in errors
+	dir string
+	// IsDraft is set for the sequentials of a drafts folder, which are left out of a push unless drafts are included
+	IsDraft bool `yaml:"-"`
 }
 
 func (seq *Sequential) GetDisplayName() string {
@@ -56,8 +74,19 @@ func (seq *Sequential) GetAssignmentType() string {
 	return seq.Format
 }
 
+func (seq *Sequential) GetIsDraft() bool {
+	return seq.IsDraft
+}
+
 func (seq *Sequential) GetExtraAttributes() map[string]string {
-	return map[string]string{}
+	attrs := seq.OutlineInfo.extraAttributes()
+	translationExtraAttributes(seq.Translated, attrs)
+	if seq.Exam != nil {
+		for k, v := range seq.Exam.extraAttributes() {
+			attrs[k] = v
+		}
+	}
+	return attrs
 }
 
 func (seq *Sequential) GetVerticals() []ir.Vertical {
@@ -67,3 +96,7 @@ func (seq *Sequential) GetVerticals() []ir.Vertical {
 func (seq *Sequential) SetUpdatedAt(updatedAt time.Time) {
 	seq.UpdatedAt = updatedAt
 }
+
+func (seq *Sequential) SetCreatedAt(createdAt time.Time) {
+	seq.CreatedAt = createdAt
+}
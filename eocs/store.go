@@ -0,0 +1,53 @@
+package eocs
+
+import (
+	"errors"
+	"github.com/exlskills/eocsutil/config"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"strings"
+)
+
+// jsonFileStoreURIPrefix selects the jsonFileStore, which writes the records to files instead of loading them
+const jsonFileStoreURIPrefix = "file+json://"
+
+// courseStore is where upsertCourseRecursive loads a course into. The records are grouped in collections named after the
+// MongoDB ones (course, exam, question, versioned_content and the push state), the search docs go to a search index
+type courseStore interface {
+	// UpsertRecord inserts the record or replaces the existing one with the same ID
+	UpsertRecord(collection, id string, record interface{}) error
+	// FindRecord reads the record into out, returning false if there is no record with the ID
+	FindRecord(collection, id string, out interface{}) (bool, error)
+	// RemoveRecord deletes the record, a record that does not exist is not an error
+	RemoveRecord(collection, id string) error
+	// FindRecordIDs returns those of the IDs that have a record in the collection
+	FindRecordIDs(collection string, ids []string) ([]string, error)
+	// FindCourseQuestionIDs lists the IDs of all the questions that reference the course
+	FindCourseQuestionIDs(courseID string) ([]string, error)
+	// HasSearchIndex returns false when search docs are not stored at all
+	HasSearchIndex() bool
+	// PrepareSearchIndex creates the index behind the alias if needed, or rebuilds it with the current mapping if reindex
+	// is set
+	PrepareSearchIndex(alias, lang string, reindex bool) error
+	IndexSearchDocs(index string, docs []*esmodels.ElasticsearchGenDoc) error
+	// FindCourseSearchDocIDs lists the IDs of all the docs of the course in the index
+	FindCourseSearchDocIDs(index, courseID string) ([]string, error)
+	// RemoveSearchDoc deletes the doc, a doc that does not exist is not an error
+	RemoveSearchDoc(index, id string) error
+	Close()
+}
+
+func isCourseStoreURI(uri string) bool {
+	return strings.HasPrefix(uri, "mongodb://") || strings.HasPrefix(uri, jsonFileStoreURIPrefix)
+}
+
+// newCourseStore returns the store for the URI: a directory for file+json://, otherwise MongoDB (along with
+// Elasticsearch if ELASTICSEARCH_URI is set)
+func newCourseStore(uri string) (courseStore, error) {
+	if strings.HasPrefix(uri, jsonFileStoreURIPrefix) {
+		return newJSONFileStore(strings.TrimPrefix(uri, jsonFileStoreURIPrefix))
+	}
+	if config.Cfg().MgoDBName == "" {
+		return nil, errors.New("for EOCS course conversion the MGO_DB_NAME environment variable must be set to the name of the MongoDB database to write to")
+	}
+	return newMongoESStore(uri, config.Cfg().MgoDBName, config.Cfg().ElasticsearchURI)
+}
@@ -0,0 +1,268 @@
+package eocs
+
+import (
+	"encoding/json"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/globalsign/mgo/bson"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jsonFileStore writes everything that would be loaded into MongoDB and Elasticsearch to JSON files, one file per record
+// in <dir>/<collection>/<id>.json and per search doc in <dir>/search/<index>/<id>.json. Along with the push state, this
+// makes the output of a push easy to inspect and to compare against golden files
+type jsonFileStore struct {
+	rootDir string
+}
+
+func newJSONFileStore(dir string) (*jsonFileStore, error) {
+	rootDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(rootDir, 0775)
+	if err != nil {
+		return nil, err
+	}
+	Log.Infof("Writing the course records to %s", rootDir)
+	return &jsonFileStore{rootDir: rootDir}, nil
+}
+
+func (store *jsonFileStore) recordPath(dir, id string) string {
+	// The search doc IDs are base64, which may contain a slash
+	return filepath.Join(store.rootDir, dir, url.PathEscape(id)+".json")
+}
+
+func (store *jsonFileStore) writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0775)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0664)
+}
+
+func (store *jsonFileStore) UpsertRecord(collection, id string, record interface{}) error {
+	doc, err := recordToBSONDoc(record)
+	if err != nil {
+		return err
+	}
+	// Same as the upsert selector in MongoDB
+	doc["_id"] = id
+	return store.writeJSON(store.recordPath(collection, id), bsonToExtJSON(doc))
+}
+
+func (store *jsonFileStore) FindRecord(collection, id string, out interface{}) (bool, error) {
+	b, err := ioutil.ReadFile(store.recordPath(collection, id))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	var v interface{}
+	err = json.Unmarshal(b, &v)
+	if err != nil {
+		return false, err
+	}
+	raw, err := bson.Marshal(extJSONToBSON(v))
+	if err != nil {
+		return false, err
+	}
+	return true, bson.Unmarshal(raw, out)
+}
+
+func (store *jsonFileStore) RemoveRecord(collection, id string) error {
+	err := os.Remove(store.recordPath(collection, id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (store *jsonFileStore) FindRecordIDs(collection string, ids []string) ([]string, error) {
+	var found []string
+	for _, id := range ids {
+		_, err := os.Stat(store.recordPath(collection, id))
+		if err == nil {
+			found = append(found, id)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+func (store *jsonFileStore) FindCourseQuestionIDs(courseID string) ([]string, error) {
+	listing, err := ioutil.ReadDir(filepath.Join(store.rootDir, pushKindQuestion))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, fi := range listing {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(store.rootDir, pushKindQuestion, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		// The files have the field names of the documents, which the esmodels types only have as bson tags
+		q := struct {
+			ID            string `json:"_id"`
+			CourseItemRef struct {
+				CourseID string `json:"course_id"`
+			} `json:"course_item_ref"`
+			DocRef struct {
+				EmbeddedDocRef struct {
+					EmbeddedDocRefs []struct {
+						DocID string `json:"doc_id"`
+						Level string `json:"level"`
+					} `json:"embedded_doc_refs"`
+				} `json:"EmbeddedDocRef"`
+			} `json:"doc_ref"`
+		}{}
+		err = json.Unmarshal(b, &q)
+		if err != nil {
+			return nil, err
+		}
+		refsCourse := q.CourseItemRef.CourseID == courseID
+		for _, ref := range q.DocRef.EmbeddedDocRef.EmbeddedDocRefs {
+			refsCourse = refsCourse || (ref.Level == "course" && ref.DocID == courseID)
+		}
+		if refsCourse {
+			ids = append(ids, q.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (store *jsonFileStore) HasSearchIndex() bool {
+	return true
+}
+
+// PrepareSearchIndex writes the settings and mapping that the index would be created with to search/<alias>.json
+func (store *jsonFileStore) PrepareSearchIndex(alias, lang string, reindex bool) error {
+	return store.writeJSON(filepath.Join(store.rootDir, "search", alias+".json"), esIndexBody(lang))
+}
+
+func (store *jsonFileStore) IndexSearchDocs(index string, docs []*esmodels.ElasticsearchGenDoc) error {
+	for _, esd := range docs {
+		err := store.writeJSON(store.recordPath(filepath.Join("search", index), esd.ID), esd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *jsonFileStore) FindCourseSearchDocIDs(index, courseID string) ([]string, error) {
+	dir := filepath.Join(store.rootDir, "search", index)
+	listing, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, fi := range listing {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		esd := esmodels.ElasticsearchGenDoc{}
+		err = json.Unmarshal(b, &esd)
+		if err != nil {
+			return nil, err
+		}
+		if esd.CourseId != courseID {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(fi.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (store *jsonFileStore) RemoveSearchDoc(index, id string) error {
+	return store.RemoveRecord(filepath.Join("search", index), id)
+}
+
+func (store *jsonFileStore) Close() {}
+
+// recordToBSONDoc goes through BSON so that the document has exactly the fields that MongoDB would get
+func recordToBSONDoc(record interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	err = bson.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+// bsonToExtJSON replaces the ObjectIds and dates with their MongoDB extended JSON form, which keeps them apart from the
+// plain strings when the record is read back
+func bsonToExtJSON(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case bson.M:
+		return bsonToExtJSON(map[string]interface{}(tv))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(tv))
+		for k, child := range tv {
+			out[k] = bsonToExtJSON(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(tv))
+		for i, child := range tv {
+			out[i] = bsonToExtJSON(child)
+		}
+		return out
+	case bson.ObjectId:
+		return map[string]interface{}{"$oid": tv.Hex()}
+	case time.Time:
+		return map[string]interface{}{"$date": tv.UTC().Format(time.RFC3339Nano)}
+	}
+	return v
+}
+
+func extJSONToBSON(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		if oid, ok := tv["$oid"].(string); ok && len(tv) == 1 {
+			return bson.ObjectIdHex(oid)
+		}
+		if date, ok := tv["$date"].(string); ok && len(tv) == 1 {
+			if t, err := time.Parse(time.RFC3339Nano, date); err == nil {
+				return t
+			}
+		}
+		out := make(bson.M, len(tv))
+		for k, child := range tv {
+			out[k] = extJSONToBSON(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(tv))
+		for i, child := range tv {
+			out[i] = extJSONToBSON(child)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package eocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files with the output of the tests")
+
+// TestJSONFileStoreGolden pushes the course in testdata/golden_course to a file+json store and compares the files with
+// testdata/golden_course.json. Run the test with -update to rewrite them after an intended change of the output
+func TestJSONFileStoreGolden(t *testing.T) {
+	course, err := resolveCourseRecursive(filepath.Join("testdata", "golden_course"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The push takes its times from git, which has other ones for each clone
+	stampGoldenTimes(course)
+	outDir, err := ioutil.TempDir("", "eocs-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+	store, err := newCourseStore(jsonFileStoreURIPrefix + outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = upsertCourseRecursive(course, store, "learn", pushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readGoldenDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldenDir := filepath.Join("testdata", "golden_course.json")
+	if *updateGolden {
+		err = writeGoldenDir(goldenDir, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := readGoldenDir(goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, contents := range got {
+		wantContents, ok := want[path]
+		if !ok {
+			t.Errorf("%s is not in the golden files", path)
+			continue
+		}
+		if !bytes.Equal(contents, wantContents) {
+			t.Errorf("%s differs from the golden file, got\n%s\nwant\n%s", path, contents, wantContents)
+		}
+	}
+	for path := range want {
+		if _, ok := got[path]; !ok {
+			t.Errorf("%s was not written", path)
+		}
+	}
+}
+
+func stampGoldenTimes(course *Course) {
+	createdAt := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	updatedAt := time.Date(2018, 6, 7, 8, 9, 10, 0, time.UTC)
+	course.SetContentCreatedAt(createdAt)
+	course.SetContentUpdatedAt(updatedAt)
+	for _, chap := range course.Chapters {
+		chap.SetCreatedAt(createdAt)
+		chap.SetUpdatedAt(updatedAt)
+		for _, seq := range chap.Sequentials {
+			seq.SetCreatedAt(createdAt)
+			seq.SetUpdatedAt(updatedAt)
+			for _, vert := range seq.Verticals {
+				vert.SetCreatedAt(createdAt)
+				vert.SetUpdatedAt(updatedAt)
+			}
+		}
+	}
+}
+
+// readGoldenDir reads the files below dir by their slash separated paths. The time of the push state is left out, since
+// it is the time of the push
+func readGoldenDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if strings.HasPrefix(relPath, esmodels.PushStateCollection+"/") {
+			doc := map[string]interface{}{}
+			err = json.Unmarshal(contents, &doc)
+			if err != nil {
+				return err
+			}
+			delete(doc, "updated_at")
+			contents, err = json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				return err
+			}
+			contents = append(contents, '\n')
+		}
+		files[relPath] = contents
+		return nil
+	})
+	return files, err
+}
+
+func writeGoldenDir(dir string, files map[string][]byte) error {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return err
+	}
+	for relPath, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(relPath))
+		err = os.MkdirAll(filepath.Dir(path), 0775)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(path, contents, 0664)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
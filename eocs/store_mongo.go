@@ -0,0 +1,130 @@
+package eocs
+
+import (
+	"context"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/olivere/elastic"
+	"time"
+)
+
+// mongoESStore loads the records into MongoDB and the search docs into Elasticsearch, which is skipped if it has no URI
+type mongoESStore struct {
+	sess     *mgo.Session
+	db       *mgo.Database
+	esClient *elastic.Client
+}
+
+func newMongoESStore(mongoURI, dbName, elasticsearchURI string) (*mongoESStore, error) {
+	sess, err := mgo.DialWithTimeout(mongoURI, time.Duration(10*time.Second))
+	if err != nil {
+		Log.Error("MongoDB error", err)
+		return nil, err
+	}
+	store := &mongoESStore{
+		sess: sess,
+		db:   sess.DB(dbName),
+	}
+	if len(elasticsearchURI) > 0 {
+		store.esClient, err = newElasticsearchClient(elasticsearchURI)
+		if err != nil {
+			sess.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func (store *mongoESStore) UpsertRecord(collection, id string, record interface{}) error {
+	_, err := store.db.C(collection).UpsertId(id, record)
+	if err != nil {
+		Log.Errorf("MongoDB error with '%s' object: %v, and error: %s", collection, record, err.Error())
+	}
+	return err
+}
+
+func (store *mongoESStore) FindRecord(collection, id string, out interface{}) (bool, error) {
+	err := store.db.C(collection).FindId(id).One(out)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (store *mongoESStore) RemoveRecord(collection, id string) error {
+	err := store.db.C(collection).RemoveId(id)
+	if err != nil && err != mgo.ErrNotFound {
+		Log.Errorf("MongoDB error removing '%s' object %s: %s", collection, id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (store *mongoESStore) FindRecordIDs(collection string, ids []string) ([]string, error) {
+	var stored []struct {
+		ID string `bson:"_id"`
+	}
+	err := store.db.C(collection).Find(bson.M{"_id": bson.M{"$in": ids}}).Select(bson.M{"_id": 1}).All(&stored)
+	if err != nil {
+		Log.Errorf("MongoDB error looking up '%s' objects: %s", collection, err.Error())
+		return nil, err
+	}
+	found := make([]string, 0, len(stored))
+	for _, s := range stored {
+		found = append(found, s.ID)
+	}
+	return found, nil
+}
+
+func (store *mongoESStore) FindCourseQuestionIDs(courseID string) ([]string, error) {
+	var stored []struct {
+		ID string `bson:"_id"`
+	}
+	// The questions written before the course_item_ref was added only reference the course in their doc_ref
+	err := store.db.C(pushKindQuestion).Find(bson.M{"$or": []bson.M{
+		{"course_item_ref.course_id": courseID},
+		{"doc_ref.EmbeddedDocRef.embedded_doc_refs": bson.M{"$elemMatch": bson.M{"doc_id": courseID, "level": "course"}}},
+	}}).Select(bson.M{"_id": 1}).All(&stored)
+	if err != nil {
+		Log.Errorf("MongoDB error listing the questions of course %s: %s", courseID, err.Error())
+		return nil, err
+	}
+	ids := make([]string, 0, len(stored))
+	for _, s := range stored {
+		ids = append(ids, s.ID)
+	}
+	return ids, nil
+}
+
+func (store *mongoESStore) HasSearchIndex() bool {
+	return store.esClient != nil
+}
+
+func (store *mongoESStore) PrepareSearchIndex(alias, lang string, reindex bool) error {
+	if reindex {
+		return reindexSearchAlias(store.esClient, alias, lang)
+	}
+	return ensureSearchIndex(store.esClient, alias, lang)
+}
+
+func (store *mongoESStore) IndexSearchDocs(index string, docs []*esmodels.ElasticsearchGenDoc) error {
+	return bulkIndexSearchDocs(store.esClient, index, docs)
+}
+
+func (store *mongoESStore) FindCourseSearchDocIDs(index, courseID string) ([]string, error) {
+	return findSearchDocIDs(store.esClient, index, elastic.NewTermQuery("course_id", courseID))
+}
+
+func (store *mongoESStore) RemoveSearchDoc(index, id string) error {
+	_, err := store.esClient.Delete().Index(index).Type("_doc").Id(id).Do(context.Background())
+	if err != nil && !elastic.IsNotFound(err) {
+		Log.Errorf("Elasticsearch error removing doc %s: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (store *mongoESStore) Close() {
+	store.sess.Close()
+}
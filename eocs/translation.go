@@ -0,0 +1,447 @@
+package eocs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/exlskills/eocsutil/diagnostics"
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"github.com/exlskills/eocsutil/olx/olxproblems"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// localeRegex matches the locales that a course can be translated to, e.g. fr or pt-BR
+var localeRegex = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// Translation is an index.<locale>.yaml overlay, which translates the texts of the index.yaml next to it. Anything it
+// leaves out stays in the course language. The info_md only applies to the course
+type Translation struct {
+	DisplayName string `yaml:"display_name,omitempty"`
+	Headline    string `yaml:"headline,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	InfoMD      string `yaml:"info_md,omitempty"`
+}
+
+// translationFileName names the overlay of a file: index.yaml becomes index.<locale>.yaml and 01_Intro.prob.md becomes
+// 01_Intro.prob.<locale>.md
+func translationFileName(name, locale string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + locale + ext
+}
+
+// isTranslationFile tells the overlays apart from the blocks of a vertical
+func isTranslationFile(name string, locales []string) bool {
+	for _, locale := range locales {
+		if strings.HasSuffix(name, "."+locale+".md") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTranslations checks the locales that the course index.yaml lists under `translations`
+func (course *Course) validateTranslations() error {
+	seen := map[string]bool{}
+	for _, locale := range course.TranslationLocales {
+		if !localeRegex.MatchString(locale) {
+			return errors.New(fmt.Sprintf("invalid translations in index.yaml: %q is not a locale such as fr or pt-BR", locale))
+		}
+		if locale == course.Language {
+			return errors.New(fmt.Sprintf("invalid translations in index.yaml: %s is the language of the course", locale))
+		}
+		if seen[locale] {
+			return errors.New(fmt.Sprintf("invalid translations in index.yaml: %s is listed twice", locale))
+		}
+		seen[locale] = true
+	}
+	return nil
+}
+
+// readTranslations reads the index.<locale>.yaml overlays of the directory, returning nil if there are none
+func readTranslations(dir string, locales []string) (map[string]*Translation, error) {
+	var translated map[string]*Translation
+	for _, locale := range locales {
+		path := filepath.Join(dir, translationFileName("index.yaml", locale))
+		b, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		t := &Translation{}
+		err = yaml.UnmarshalStrict(b, t)
+		if err != nil {
+			return nil, &fileError{path: path, line: diagnostics.LineFromYAMLError(err), err: err}
+		}
+		if translated == nil {
+			translated = map[string]*Translation{}
+		}
+		translated[locale] = t
+	}
+	return translated, nil
+}
+
+// readBlockTranslations reads the overlays of the markdown file of a html or problem block, returning nil if there are
+// none. A translated problem must parse, and its front matter may only translate the explanation
+func readBlockTranslations(dir, name string, locales []string, problem bool) (map[string]string, error) {
+	var translated map[string]string
+	for _, locale := range locales {
+		path := filepath.Join(dir, translationFileName(name, locale))
+		b, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if problem {
+			_, _, err = translatedProblem(string(b), nil)
+			if err != nil {
+				return nil, &fileError{path: path, line: errorLine(err), err: err}
+			}
+		}
+		if translated == nil {
+			translated = map[string]string{}
+		}
+		translated[locale] = string(b)
+	}
+	return translated, nil
+}
+
+// translatedProblem splits the overlay of a problem into the markdown and the meta of the translated block, which is
+// the original meta with the translated explanation
+func translatedProblem(contents string, meta *ProblemMeta) (*ProblemMeta, string, error) {
+	trMeta, md, err := splitProblemFrontMatter(contents)
+	if err != nil {
+		return nil, "", err
+	}
+	if trMeta != nil {
+		if !reflect.DeepEqual(*trMeta, ProblemMeta{Explanation: trMeta.Explanation}) {
+			return nil, "", errors.New("invalid front matter: only the explanation of a problem is translated, the other settings are taken from the original")
+		}
+		translatedMeta := ProblemMeta{}
+		if meta != nil {
+			translatedMeta = *meta
+		}
+		translatedMeta.Explanation = trMeta.Explanation
+		meta = &translatedMeta
+	}
+	_, err = olxproblems.NewProblemFromMD(md)
+	if err != nil {
+		return nil, "", err
+	}
+	return meta, md, nil
+}
+
+// applyTo overwrites the texts that the translation has, a nil translation leaves them all as they are
+func (t *Translation) applyTo(displayName, headline, description, infoMD *string) {
+	if t == nil {
+		return
+	}
+	for _, f := range []struct{ dst, src *string }{
+		{displayName, &t.DisplayName},
+		{headline, &t.Headline},
+		{description, &t.Description},
+		{infoMD, &t.InfoMD},
+	} {
+		if f.dst != nil && *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+}
+
+// translationExtraAttributes carries the translations in the extra attributes as <key>.<locale>, e.g. display_name.fr
+func translationExtraAttributes(translated map[string]*Translation, attrs map[string]string) {
+	for locale, t := range translated {
+		for k, v := range map[string]string{
+			"display_name": t.DisplayName,
+			"headline":     t.Headline,
+			"description":  t.Description,
+			"info_md":      t.InfoMD,
+		} {
+			if v != "" {
+				attrs[k+"."+locale] = v
+			}
+		}
+	}
+}
+
+// translationsFromExtraAttributes is the reverse of translationExtraAttributes, returning nil if there are none
+func translationsFromExtraAttributes(attrs map[string]string) map[string]*Translation {
+	var translated map[string]*Translation
+	for k, v := range attrs {
+		dot := strings.Index(k, ".")
+		if dot < 0 || v == "" || !localeRegex.MatchString(k[dot+1:]) {
+			continue
+		}
+		locale := k[dot+1:]
+		t, ok := translated[locale]
+		if !ok {
+			t = &Translation{}
+		}
+		switch k[:dot] {
+		case "display_name":
+			t.DisplayName = v
+		case "headline":
+			t.Headline = v
+		case "description":
+			t.Description = v
+		case "info_md":
+			t.InfoMD = v
+		default:
+			continue
+		}
+		if translated == nil {
+			translated = map[string]*Translation{}
+		}
+		translated[locale] = t
+	}
+	return translated
+}
+
+// blockTranslationExtraAttributes carries the overlays of a block as content_md.<locale>
+func blockTranslationExtraAttributes(translated map[string]string, attrs map[string]string) {
+	for locale, md := range translated {
+		attrs["content_md."+locale] = md
+	}
+}
+
+func blockTranslationsFromExtraAttributes(attrs map[string]string) map[string]string {
+	var translated map[string]string
+	for k, v := range attrs {
+		if !strings.HasPrefix(k, "content_md.") || v == "" {
+			continue
+		}
+		if translated == nil {
+			translated = map[string]string{}
+		}
+		translated[strings.TrimPrefix(k, "content_md.")] = v
+	}
+	return translated
+}
+
+// writeTranslations writes the index.<locale>.yaml overlays of the directory
+func writeTranslations(dir string, translated map[string]*Translation) error {
+	for locale, t := range translated {
+		b, err := yaml.Marshal(t)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, translationFileName("index.yaml", locale)), b, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localizedCourse returns a copy of the course with the texts of the locale in place of the original ones, as far as
+// they are translated
+func localizedCourse(course *Course, locale string) (*Course, error) {
+	lc := *course
+	lc.Language = locale
+	lc.TranslationLocales = nil
+	lc.Translated[locale].applyTo(&lc.DisplayName, &lc.Headline, &lc.Description, &lc.InfoMD)
+	lc.Chapters = make([]*Chapter, 0, len(course.Chapters))
+	for _, chap := range course.Chapters {
+		lChap := *chap
+		chap.Translated[locale].applyTo(&lChap.DisplayName, &lChap.Headline, &lChap.Description, nil)
+		lChap.Sequentials = make([]*Sequential, 0, len(chap.Sequentials))
+		for _, seq := range chap.Sequentials {
+			lSeq := *seq
+			seq.Translated[locale].applyTo(&lSeq.DisplayName, &lSeq.Headline, &lSeq.Description, nil)
+			lSeq.Verticals = make([]*Vertical, 0, len(seq.Verticals))
+			for _, vert := range seq.Verticals {
+				lVert := *vert
+				vert.Translated[locale].applyTo(&lVert.DisplayName, &lVert.Headline, &lVert.Description, nil)
+				lVert.Blocks = make([]*Block, 0, len(vert.Blocks))
+				for _, blk := range vert.Blocks {
+					lBlk := *blk
+					if contents, ok := blk.Translated[locale]; ok {
+						if blk.BlockType == "problem" {
+							var err error
+							lBlk.Meta, lBlk.Markdown, err = translatedProblem(contents, blk.Meta)
+							if err != nil {
+								return nil, errors.New(fmt.Sprintf("%s translation of %s: %s", locale, blk.FSPath, err.Error()))
+							}
+						} else {
+							lBlk.Markdown = contents
+						}
+					}
+					lVert.Blocks = append(lVert.Blocks, &lBlk)
+				}
+				lSeq.Verticals = append(lSeq.Verticals, &lVert)
+			}
+			lChap.Sequentials = append(lChap.Sequentials, &lSeq)
+		}
+		lc.Chapters = append(lc.Chapters, &lChap)
+	}
+	return &lc, nil
+}
+
+var intlStringWrapperType = reflect.TypeOf(esmodels.IntlStringWrapper{})
+
+// addRecordTranslations adds the texts of src, converted from the localized course, to dst, converted from the course
+func addRecordTranslations(dst, src interface{}, locale, path string) error {
+	return addTranslations(reflect.ValueOf(dst), reflect.ValueOf(src), locale, path)
+}
+
+// addTranslations walks a record converted from the course and the same record converted from its localized copy side
+// by side, adding the texts of the latter to the IntlStringWrappers of the former as non-default strings of the locale.
+// The texts that are the same in both are not translated, so they are left out
+func addTranslations(dst, src reflect.Value, locale, path string) error {
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() || src.IsNil() {
+			return nil
+		}
+		return addTranslations(dst.Elem(), src.Elem(), locale, path)
+	case reflect.Interface:
+		if dst.IsNil() || src.IsNil() {
+			return nil
+		}
+		if dst.Elem().Type() != src.Elem().Type() {
+			return errors.New(fmt.Sprintf("%s is of another kind in the %s translation", path, locale))
+		}
+		// What an interface holds can't be set in place
+		elem := reflect.New(dst.Elem().Type()).Elem()
+		elem.Set(dst.Elem())
+		err := addTranslations(elem, src.Elem(), locale, path)
+		if err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case reflect.Slice:
+		if dst.Len() != src.Len() {
+			return errors.New(fmt.Sprintf("%s has %d items in the %s translation instead of %d", path, src.Len(), locale, dst.Len()))
+		}
+		for i := 0; i < dst.Len(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if id := recordID(dst.Index(i)); id != "" {
+				itemPath = fmt.Sprintf("%s[%s]", path, id)
+			}
+			err := addTranslations(dst.Index(i), src.Index(i), locale, itemPath)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if dst.Type() == intlStringWrapperType {
+			orig := dst.Interface().(esmodels.IntlStringWrapper)
+			translation := src.Interface().(esmodels.IntlStringWrapper).Default().Content
+			if translation != "" && translation != orig.Default().Content {
+				dst.Set(reflect.ValueOf(orig.WithTranslation(translation, locale)))
+			}
+			return nil
+		}
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			err := addTranslations(dst.Field(i), src.Field(i), locale, path+"."+field.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// untranslatedItems lists the parts of the course that have no overlay for the locale
+func untranslatedItems(course *Course, locale string) []string {
+	var items []string
+	if course.Translated[locale] == nil {
+		items = append(items, "index.yaml of the course")
+	}
+	for _, chap := range course.Chapters {
+		if chap.Translated[locale] == nil {
+			items = append(items, outlineItemName("chapter", chap.DisplayName, chap.dir))
+		}
+		for _, seq := range chap.Sequentials {
+			if seq.Translated[locale] == nil {
+				items = append(items, outlineItemName("sequential", seq.DisplayName, seq.dir))
+			}
+			for _, vert := range seq.Verticals {
+				if vert.Translated[locale] == nil {
+					items = append(items, outlineItemName("vertical", vert.DisplayName, vert.dir))
+				}
+				for _, blk := range vert.Blocks {
+					if (blk.BlockType == "html" || blk.BlockType == "problem") && blk.Translated[locale] == "" {
+						items = append(items, blk.FSPath)
+					}
+				}
+			}
+		}
+	}
+	return items
+}
+
+func outlineItemName(kind, displayName, dir string) string {
+	if dir == "" {
+		return fmt.Sprintf("index.yaml of %s %q", kind, displayName)
+	}
+	return filepath.Join(dir, "index.yaml")
+}
+
+// logUntranslated reports what is left to translate for each locale of the course
+func logUntranslated(course *Course) {
+	locales := append([]string{}, course.TranslationLocales...)
+	sort.Strings(locales)
+	for _, locale := range locales {
+		items := untranslatedItems(course, locale)
+		if len(items) == 0 {
+			Log.Infof("Course %s is fully translated to %s", course.URLName, locale)
+			continue
+		}
+		Log.Warnf("%d items of course %s are not translated to %s and fall back to %s:", len(items), course.URLName, locale, course.Language)
+		for _, item := range items {
+			Log.Warnf("  %s", item)
+		}
+	}
+}
+
+// searchDocKind is the push state kind of the search docs of the locale. The docs of the course language keep the plain
+// kind, the ones of a translation get a kind of their own since they go to another index
+func searchDocKind(course *Course, locale string) string {
+	if locale == course.GetLanguage() {
+		return pushKindSearchDoc
+	}
+	return pushKindSearchDoc + "_" + locale
+}
+
+// searchDocLocale returns the language of the search doc
+func searchDocLocale(course *Course, esd *esmodels.ElasticsearchGenDoc) string {
+	if esd.Locale == "" {
+		return course.GetLanguage()
+	}
+	return esd.Locale
+}
+
+// searchDocAlias is the index alias of the search docs of the kind, which may be of a translation that was since dropped
+func searchDocAlias(course *Course, elasticsearchIndex, kind string) string {
+	if kind == pushKindSearchDoc {
+		return elasticsearchIndex + "_" + course.GetLanguage()
+	}
+	return elasticsearchIndex + "_" + strings.TrimPrefix(kind, pushKindSearchDoc+"_")
+}
+
+// recordID returns the ID of a record, or "" if it has none, to name it in the errors
+func recordID(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	id := v.FieldByName("ID")
+	if !id.IsValid() || id.Kind() != reflect.String {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,333 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateTranslations(t *testing.T) {
+	tests := []struct {
+		name    string
+		locales []string
+		wantErr string
+	}{
+		{name: "no translations"},
+		{name: "languages and regions", locales: []string{"fr", "pt-BR", "zh-Hans"}},
+		{name: "not a locale", locales: []string{"French"}, wantErr: `"French" is not a locale such as fr or pt-BR`},
+		{name: "the language of the course", locales: []string{"fr", "en"}, wantErr: "en is the language of the course"},
+		{name: "a locale listed twice", locales: []string{"fr", "es", "fr"}, wantErr: "fr is listed twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			course := &Course{Language: "en", TranslationLocales: tt.locales}
+			err := course.validateTranslations()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTranslationFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		want   string
+	}{
+		{name: "index.yaml", locale: "fr", want: "index.fr.yaml"},
+		{name: "00_Text.md", locale: "pt-BR", want: "00_Text.pt-BR.md"},
+		{name: "01_Intro.prob.md", locale: "fr", want: "01_Intro.prob.fr.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := translationFileName(tt.name, tt.locale)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if strings.HasSuffix(got, ".md") && !isTranslationFile(got, []string{"es", tt.locale}) {
+				t.Errorf("%s is not taken for an overlay", got)
+			}
+			if isTranslationFile(tt.name, []string{tt.locale}) {
+				t.Errorf("%s is taken for an overlay", tt.name)
+			}
+		})
+	}
+}
+
+func TestTranslatedProblem(t *testing.T) {
+	meta := &ProblemMeta{Complexity: 3, Tags: []string{"loops"}, Explanation: "Because"}
+	tests := []struct {
+		name     string
+		contents string
+		wantMeta *ProblemMeta
+		wantMD   string
+		wantErr  string
+	}{
+		{
+			name:     "the original meta is kept",
+			contents: ">>¿Cuál?<<\n\n(x) Este\n( ) Ese\n",
+			wantMeta: meta,
+			wantMD:   ">>¿Cuál?<<\n\n(x) Este\n( ) Ese\n",
+		},
+		{
+			name:     "the explanation is translated",
+			contents: "---\nexplanation: Porque\n---\n>>¿Cuál?<<\n\n(x) Este\n( ) Ese\n",
+			wantMeta: &ProblemMeta{Complexity: 3, Tags: []string{"loops"}, Explanation: "Porque"},
+			wantMD:   ">>¿Cuál?<<\n\n(x) Este\n( ) Ese\n",
+		},
+		{
+			name:     "other settings can't be translated",
+			contents: "---\ncomplexity: 2\n---\n>>¿Cuál?<<\n\n(x) Este\n( ) Ese\n",
+			wantErr:  "only the explanation of a problem is translated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMeta, gotMD, err := translatedProblem(tt.contents, meta)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotMeta, tt.wantMeta) {
+				t.Errorf("got the meta %+v, want %+v", gotMeta, tt.wantMeta)
+			}
+			if gotMD != tt.wantMD {
+				t.Errorf("got the markdown %q, want %q", gotMD, tt.wantMD)
+			}
+		})
+	}
+	if meta.Explanation != "Because" {
+		t.Errorf("the original meta was changed")
+	}
+}
+
+func TestTranslationExtraAttributes(t *testing.T) {
+	translated := map[string]*Translation{
+		"fr":    {DisplayName: "Boucles", Description: "Les boucles"},
+		"pt-BR": {Headline: "Laços", InfoMD: "# Laços"},
+	}
+	attrs := map[string]string{"display_name": "Loops", "weight.x": "1"}
+	translationExtraAttributes(translated, attrs)
+	want := map[string]string{
+		"display_name":    "Loops",
+		"weight.x":        "1",
+		"display_name.fr": "Boucles",
+		"description.fr":  "Les boucles",
+		"headline.pt-BR":  "Laços",
+		"info_md.pt-BR":   "# Laços",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("got the attributes %v, want %v", attrs, want)
+	}
+	got := translationsFromExtraAttributes(attrs)
+	if !reflect.DeepEqual(got, translated) {
+		t.Errorf("got the translations %+v back, want %+v", got, translated)
+	}
+	if got := translationsFromExtraAttributes(map[string]string{"display_name": "Loops"}); got != nil {
+		t.Errorf("got the translations %+v out of no translated attributes, want none", got)
+	}
+}
+
+func TestAddRecordTranslations(t *testing.T) {
+	type choice struct {
+		ID   string
+		Text esmodels.IntlStringWrapper
+	}
+	type record struct {
+		Title   esmodels.IntlStringWrapper
+		Choices []choice
+		Data    interface{}
+	}
+	tests := []struct {
+		name    string
+		dst     *record
+		src     *record
+		want    *record
+		wantErr string
+	}{
+		{
+			name: "the translated texts are added",
+			dst: &record{
+				Title:   esmodels.NewIntlStringWrapper("Loops", "en"),
+				Choices: []choice{{ID: "a", Text: esmodels.NewIntlStringWrapper("for", "en")}, {ID: "b", Text: esmodels.NewIntlStringWrapper("while", "en")}},
+				Data:    choice{Text: esmodels.NewIntlStringWrapper("Because", "en")},
+			},
+			src: &record{
+				Title:   esmodels.NewIntlStringWrapper("Bucles", "es"),
+				Choices: []choice{{ID: "a", Text: esmodels.NewIntlStringWrapper("for", "es")}, {ID: "b", Text: esmodels.NewIntlStringWrapper("mientras", "es")}},
+				Data:    choice{Text: esmodels.NewIntlStringWrapper("Porque", "es")},
+			},
+			want: &record{
+				Title:   esmodels.NewIntlStringWrapper("Loops", "en").WithTranslation("Bucles", "es"),
+				Choices: []choice{{ID: "a", Text: esmodels.NewIntlStringWrapper("for", "en")}, {ID: "b", Text: esmodels.NewIntlStringWrapper("while", "en").WithTranslation("mientras", "es")}},
+				Data:    choice{Text: esmodels.NewIntlStringWrapper("Because", "en").WithTranslation("Porque", "es")},
+			},
+		},
+		{
+			name:    "a list of another length",
+			dst:     &record{Choices: []choice{{ID: "a"}, {ID: "b"}}},
+			src:     &record{Choices: []choice{{ID: "a"}}},
+			wantErr: "q1.Choices has 1 items in the es translation instead of 2",
+		},
+		{
+			name:    "a field of another kind",
+			dst:     &record{Data: choice{}},
+			src:     &record{Data: "text"},
+			wantErr: "q1.Data is of another kind in the es translation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := addRecordTranslations(tt.dst, tt.src, "es", "q1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got the error %v, want one with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.dst, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.dst, tt.want)
+			}
+		})
+	}
+}
+
+// TestTranslatedCoursePush adds a Spanish overlay to a copy of the testdata course and pushes it to a file+json store.
+// The translated texts are loaded as Spanish strings of the records and into a search index of their own
+func TestTranslatedCoursePush(t *testing.T) {
+	courseDir, err := ioutil.TempDir("", "eocs-translation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(courseDir)
+	err = copyTestDir(filepath.Join("testdata", "golden_course"), courseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declaringDir := filepath.Join(courseDir, "00_Basics", "00_Variables", "00_Declaring")
+	for path, contents := range map[string]string{
+		"index.yaml":    "translations: [es]\n",
+		"index.es.yaml": "display_name: Curso de oro\n",
+		filepath.Join(declaringDir, "00_Text.es.md"): "# Declarar variables\n",
+		filepath.Join(declaringDir, "index.es.yaml"): "display_name: Declarar\n",
+	} {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(courseDir, path)
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.WriteString(contents)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	course, err := resolveCourseRecursive(courseDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stampGoldenTimes(course)
+	items := untranslatedItems(course, "es")
+	for _, item := range items {
+		if strings.Contains(item, "00_Declaring") && !strings.HasSuffix(item, ".prob.md") {
+			t.Errorf("%s is listed as untranslated", item)
+		}
+	}
+	outDir, err := ioutil.TempDir("", "eocs-translation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+	store, err := newCourseStore(jsonFileStoreURIPrefix + outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = upsertCourseRecursive(course, store, "learn", pushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	esc := esmodels.Course{}
+	_, err = store.FindRecord(pushKindCourse, "golden_course", &esc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTranslation(t, "course title", esc.Title, "Golden Course", "Curso de oro")
+	vc := esmodels.VersionedContent{}
+	_, err = store.FindRecord(pushKindVersionedContent, "declaring_vc", &vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := latestContentEntry(&vc)
+	if latest == nil {
+		t.Fatal("the card content was not loaded")
+	}
+	checkTranslation(t, "card content", latest.Content, "# Declaring variables", "# Declarar variables")
+	for _, index := range []string{"learn_en", "learn_es"} {
+		docs, err := store.FindCourseSearchDocIDs(index, "golden_course")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 5 {
+			t.Errorf("got %d search docs in %s, want 5", len(docs), index)
+		}
+	}
+}
+
+// checkTranslation checks that the default string of the wrapper starts with orig and the Spanish one with es
+func checkTranslation(t *testing.T, name string, isw esmodels.IntlStringWrapper, orig, es string) {
+	t.Helper()
+	if def := isw.Default(); !strings.HasPrefix(def.Content, orig) {
+		t.Errorf("got the %s %q, want %q", name, def.Content, orig)
+	}
+	for _, s := range isw.Strings {
+		if s.Locale == "es" && !s.IsDefault {
+			if !strings.HasPrefix(s.Content, es) {
+				t.Errorf("got the Spanish %s %q, want %q", name, s.Content, es)
+			}
+			return
+		}
+	}
+	t.Errorf("the %s has no Spanish string", name)
+}
+
+func copyTestDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, contents, 0644)
+	})
+}
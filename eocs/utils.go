@@ -65,9 +65,8 @@ var ignoredDirs = map[string]struct{}{
 	".idea": {},
 	".vscode": {},
 	".":     {},
-	// TODO implement assets dir, but for now ignore
+	// The assets of the course and of the verticals are read by readAssets
 	"assets": {},
-	"drafts": {},
 }
 
 func isIgnoredDir(name string) bool {
@@ -85,6 +84,28 @@ func extraAttrCSVToStrSlice(str string) []string {
 	return strings.Split(str, ",")
 }
 
+// formatOptionalBool and the others format an optional setting as an extra attribute, which is empty when it is not set
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func formatOptionalInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
 func toGlobalId(prefix string, id string) string {
 	return base64.StdEncoding.EncodeToString([]byte(prefix + ":" + id))
 }
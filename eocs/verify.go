@@ -0,0 +1,110 @@
+package eocs
+
+import (
+	"fmt"
+	"github.com/exlskills/eocsutil/diagnostics"
+	"github.com/exlskills/eocsutil/eocsuri"
+	"path/filepath"
+	"strings"
+)
+
+// The rules that the diagnostics of a course are reported under
+const (
+	ruleIndexYAML       = "index-yaml"
+	ruleCourseSettings  = "course-settings"
+	ruleOutlineSettings = "outline-settings"
+	ruleExamSettings    = "exam-settings"
+	ruleTranslation     = "translation"
+	ruleDirName         = "dir-name"
+	ruleDirDepth        = "dir-depth"
+	ruleOutlineOrder    = "outline-order"
+	ruleURLName         = "url-name"
+	ruleAssets          = "assets"
+	ruleAssetLink       = "asset-link"
+	ruleBlock           = "block"
+	ruleProblem         = "problem"
+	ruleREPL            = "repl"
+)
+
+// Verify reads the course like Import does, but rather than failing at the first problem it goes on to report all of
+// those that it finds. The error is only for a course that cannot be read at all
+func (e *EOCS) Verify(fromUri string) ([]diagnostics.Diagnostic, error) {
+	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(fromUri)
+	if err != nil {
+		return nil, err
+	}
+	_, diags := resolveCourse(rootDir, !e.WriteIDs)
+	return diags, nil
+}
+
+// fileError is an error in a file of the course, with the line where it is known. A fileError without a path leaves the
+// file to the caller
+type fileError struct {
+	path string
+	line int
+	err  error
+}
+
+func (e *fileError) Error() string {
+	if e.path == "" {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.path, e.err.Error())
+}
+
+// errorLine returns the line that the error points at, or 0
+func errorLine(err error) int {
+	if fe, ok := err.(*fileError); ok {
+		return fe.line
+	}
+	return 0
+}
+
+// report adds the diagnostics to those of the course, with their paths made relative to the course folder. The blocks
+// are read concurrently, so it may be called from several goroutines
+func (pcx *parserCtx) report(diags ...diagnostics.Diagnostic) {
+	pcx.diagsMutex.Lock()
+	defer pcx.diagsMutex.Unlock()
+	for _, d := range diags {
+		if filepath.IsAbs(d.Path) {
+			if relPath, err := filepath.Rel(pcx.rootDir, d.Path); err == nil {
+				d.Path = relPath
+			}
+		}
+		d.Path = filepath.ToSlash(d.Path)
+		pcx.diags = append(pcx.diags, d)
+	}
+}
+
+// reportErr adds an error diagnostic of the rule for the error, pointing at path unless the error names its own file
+func (pcx *parserCtx) reportErr(rule, path string, err error) {
+	d := diagnostics.Errorf(path, errorLine(err), rule, "%s", err.Error())
+	if fe, ok := err.(*fileError); ok && fe.path != "" {
+		d.Path = fe.path
+		d.Message = fe.err.Error()
+	}
+	pcx.report(d)
+}
+
+// blockDiagnostic returns the diagnostic of a block file that failed to read
+func blockDiagnostic(fsPath string, err error) diagnostics.Diagnostic {
+	rule := ruleBlock
+	if strings.HasSuffix(fsPath, ".prob.md") {
+		rule = ruleProblem
+	} else if strings.HasSuffix(fsPath, ".repl.yaml") {
+		rule = ruleREPL
+	}
+	d := diagnostics.Errorf(fsPath, errorLine(err), rule, "%s", err.Error())
+	if fe, ok := err.(*fileError); ok && fe.path != "" {
+		d.Path = fe.path
+		d.Message = fe.err.Error()
+		if strings.HasSuffix(fe.path, ".repl.yaml") {
+			d.Rule = ruleREPL
+		}
+	}
+	return d
+}
+
+func indexYAMLPath(dir string) string {
+	return filepath.Join(dir, "index.yaml")
+}
@@ -0,0 +1,94 @@
+package eocs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	const (
+		declaringDir = "00_Basics/00_Variables/00_Declaring"
+		checkDir     = "00_Basics/00_Variables/01_Check"
+	)
+	tests := []struct {
+		name string
+		// files are written over those of the testdata course, renames are done after
+		files   map[string]string
+		renames map[string]string
+		// want are the diagnostics as path:line: rule, sorted, and wantMessages the parts of their messages
+		want         []string
+		wantMessages []string
+	}{
+		{
+			name: "the testdata course",
+		},
+		{
+			name: "all the problems are reported",
+			files: map[string]string{
+				filepath.Join(declaringDir, "00_Text.md"):     "# Declaring\n\n![Diagram](assets/diagram.png)\n",
+				filepath.Join(checkDir, "00_Keyword.prob.md"): "---\ntags: [variables]\npoints: -1\n---\n>>Which keyword declares a constant?<<\n\n[[ var, (const), let ]]\n",
+				filepath.Join(checkDir, "index.yaml"):         "url_name: check\ndisplay_name: [Check\n",
+			},
+			renames: map[string]string{"00_Basics/01_Final_Exam": "00_Basics/03_Final_Exam"},
+			want: []string{
+				"00_Basics/00_Variables/00_Declaring/00_Text.md:3: asset-link",
+				"00_Basics/00_Variables/01_Check/00_Keyword.prob.md:0: problem",
+				"00_Basics/00_Variables/01_Check/index.yaml:2: index-yaml",
+				"00_Basics/03_Final_Exam:0: outline-order",
+			},
+			wantMessages: []string{
+				"assets/diagram.png does not lead to an asset of the course",
+				"points must not be negative, got -1",
+				"",
+				"the prefix 3 follows 0, skipping 1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courseDir, err := ioutil.TempDir("", "eocs-verify")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(courseDir)
+			err = copyTestDir(filepath.Join("testdata", "golden_course"), courseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for path, contents := range tt.files {
+				err = ioutil.WriteFile(filepath.Join(courseDir, path), []byte(contents), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for from, to := range tt.renames {
+				err = os.Rename(filepath.Join(courseDir, from), filepath.Join(courseDir, to))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			diags, err := NewEOCSFormat().Verify("file://" + courseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, gotMessages []string
+			for _, d := range diags {
+				got = append(got, d.Path+":"+strconv.Itoa(d.Line)+": "+d.Rule)
+				gotMessages = append(gotMessages, d.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got the diagnostics %q, want %q", got, tt.want)
+			}
+			for i, msg := range tt.wantMessages {
+				if !strings.Contains(gotMessages[i], msg) {
+					t.Errorf("got the message %q for %s, want one with %q", gotMessages[i], got[i], msg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"strconv"
+	"time"
+)
+
+// mergeContentHistory carries the versions of the stored document over into the converted one, which only has the
+// current card content as version 1. The content becomes a new version only when it differs from the latest stored one,
+// and no more than keep versions are retained (all of them if keep is 0). It returns false if the stored document is
+// already up to date. The times stamped on the converted document are kept, the current time is only used for those
+// that are not set
+func mergeContentHistory(vc *esmodels.VersionedContent, stored *esmodels.VersionedContent, keep int) (bool, error) {
+	now := time.Now()
+	current := latestContentEntry(vc)
+	if stored == nil || current == nil {
+		if vc.CreatedAt.IsZero() {
+			vc.CreatedAt = now
+		}
+		if vc.UpdatedAt.IsZero() {
+			vc.UpdatedAt = now
+		}
+		return true, nil
+	}
+	if prev := latestContentEntry(stored); prev != nil {
+		prevHash, err := contentHash(prev.Content)
+		if err != nil {
+			return false, err
+		}
+		newHash, err := contentHash(current.Content)
+		if err != nil {
+			return false, err
+		}
+		if prevHash == newHash {
+			*vc = *stored
+			return false, nil
+		}
+	}
+
+	version := stored.LatestVersion
+	for _, c := range stored.Contents {
+		if c.Version > version {
+			version = c.Version
+		}
+	}
+	version++
+	entry := esmodels.Content{
+		ID:      esmodels.StableObjectID(vc.ID, strconv.Itoa(version)),
+		Version: version,
+		Content: current.Content,
+	}
+	contents := append(append([]esmodels.Content{}, stored.Contents...), entry)
+	if keep > 0 && len(contents) > keep {
+		contents = contents[len(contents)-keep:]
+	}
+	vc.Contents = contents
+	vc.LatestVersion = version
+	if !stored.CreatedAt.IsZero() {
+		vc.CreatedAt = stored.CreatedAt
+	}
+	if vc.CreatedAt.IsZero() {
+		vc.CreatedAt = now
+	}
+	if vc.UpdatedAt.IsZero() {
+		vc.UpdatedAt = now
+	}
+	return true, nil
+}
+
+// latestContentEntry returns the entry of LatestVersion, or the highest version if that one is missing
+func latestContentEntry(vc *esmodels.VersionedContent) *esmodels.Content {
+	var latest *esmodels.Content
+	for i := range vc.Contents {
+		if vc.Contents[i].Version == vc.LatestVersion {
+			return &vc.Contents[i]
+		}
+		if latest == nil || vc.Contents[i].Version > latest.Version {
+			latest = &vc.Contents[i]
+		}
+	}
+	return latest
+}
@@ -0,0 +1,144 @@
+package eocs
+
+import (
+	"github.com/exlskills/eocsutil/eocs/esmodels"
+	"testing"
+	"time"
+)
+
+func TestMergeContentHistory(t *testing.T) {
+	created := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	storedCreated := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	content := func(version int, md string) esmodels.Content {
+		return esmodels.Content{Version: version, Content: esmodels.NewIntlStringWrapper(md, "en")}
+	}
+	converted := func(md string, createdAt, updatedAt time.Time) *esmodels.VersionedContent {
+		return &esmodels.VersionedContent{ID: "vc", LatestVersion: 1, Contents: []esmodels.Content{content(1, md)}, CreatedAt: createdAt, UpdatedAt: updatedAt}
+	}
+	stored := func(createdAt time.Time, mds ...string) *esmodels.VersionedContent {
+		vc := &esmodels.VersionedContent{ID: "vc", LatestVersion: len(mds), CreatedAt: createdAt, UpdatedAt: createdAt}
+		for i, md := range mds {
+			vc.Contents = append(vc.Contents, content(i+1, md))
+		}
+		return vc
+	}
+	tests := []struct {
+		name         string
+		vc           *esmodels.VersionedContent
+		stored       *esmodels.VersionedContent
+		keep         int
+		wantChanged  bool
+		wantVersions []int
+		wantContent  string
+		// A zero time is expected to be set to the current time
+		wantCreated time.Time
+		wantUpdated time.Time
+	}{
+		{
+			name:         "first push keeps the stamped times",
+			vc:           converted("a", created, updated),
+			wantChanged:  true,
+			wantVersions: []int{1},
+			wantContent:  "a",
+			wantCreated:  created,
+			wantUpdated:  updated,
+		},
+		{
+			name:         "first push without stamped times",
+			vc:           converted("a", time.Time{}, time.Time{}),
+			wantChanged:  true,
+			wantVersions: []int{1},
+			wantContent:  "a",
+		},
+		{
+			name:         "unchanged content keeps the stored document",
+			vc:           converted("a", created, updated),
+			stored:       stored(storedCreated, "a"),
+			wantVersions: []int{1},
+			wantContent:  "a",
+			wantCreated:  storedCreated,
+			wantUpdated:  storedCreated,
+		},
+		{
+			name:         "changed content becomes a new version",
+			vc:           converted("b", created, updated),
+			stored:       stored(storedCreated, "a"),
+			wantChanged:  true,
+			wantVersions: []int{1, 2},
+			wantContent:  "b",
+			wantCreated:  storedCreated,
+			wantUpdated:  updated,
+		},
+		{
+			name:         "stored document without a creation time",
+			vc:           converted("b", created, time.Time{}),
+			stored:       stored(time.Time{}, "a"),
+			wantChanged:  true,
+			wantVersions: []int{1, 2},
+			wantContent:  "b",
+			wantCreated:  created,
+		},
+		{
+			name:         "only the versions to keep are retained",
+			vc:           converted("c", created, updated),
+			stored:       stored(storedCreated, "a", "b"),
+			keep:         2,
+			wantChanged:  true,
+			wantVersions: []int{2, 3},
+			wantContent:  "c",
+			wantCreated:  storedCreated,
+			wantUpdated:  updated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := mergeContentHistory(tt.vc, tt.stored, tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("got changed %v, want %v", changed, tt.wantChanged)
+			}
+			var versions []int
+			for _, c := range tt.vc.Contents {
+				versions = append(versions, c.Version)
+			}
+			if !equalInts(versions, tt.wantVersions) {
+				t.Errorf("got the versions %v, want %v", versions, tt.wantVersions)
+			}
+			latest := latestContentEntry(tt.vc)
+			if latest == nil || latest.Content.Default().Content != tt.wantContent {
+				t.Errorf("got the latest content %+v, want %q", latest, tt.wantContent)
+			}
+			checkTime(t, "created", tt.vc.CreatedAt, tt.wantCreated)
+			checkTime(t, "updated", tt.vc.UpdatedAt, tt.wantUpdated)
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTime compares a time with the expected one, where a zero expected time stands for the current time
+func checkTime(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	if want.IsZero() {
+		if time.Since(got) > time.Minute {
+			t.Errorf("got the %s time %v, want the current time", name, got)
+		}
+		return
+	}
+	if !got.Equal(want) {
+		t.Errorf("got the %s time %v, want %v", name, got, want)
+	}
+}
@@ -19,6 +19,16 @@ func appendIRVerticalsToSequential(seq *Sequential, verts []ir.Vertical) (err er
 		newV := &Vertical{
 			URLName:     v.GetURLName(),
 			DisplayName: v.GetDisplayName(),
+			IsDraft:     v.GetIsDraft(),
+		}
+		newV.OutlineInfo, err = outlineInfoFromExtraAttributes(v.GetExtraAttributes())
+		if err != nil {
+			return err
+		}
+		newV.Translated = translationsFromExtraAttributes(v.GetExtraAttributes())
+		newV.Assets, err = irAssetsToAssets(v.GetAssets())
+		if err != nil {
+			return err
 		}
 		err = appendIRBlocksToVertical(newV, v.GetBlocks())
 		if err != nil {
@@ -34,6 +44,14 @@ type Vertical struct {
 	DisplayName string    `yaml:"display_name"`
 	Blocks      []*Block  `yaml:"-"`
 	UpdatedAt   time.Time `yaml:"-"`
+	CreatedAt   time.Time `yaml:"-"`
+	OutlineInfo `yaml:",inline"`
+	Translated  map[string]*Translation `yaml:"-"`
+	// dir is the directory of the vertical relative to the course, for naming its index.yaml in reports
+	dir    string
+	Assets []*Asset `yaml:"-"`
+	// IsDraft is set for the verticals of a drafts folder, which are left out of a push unless drafts are included
+	IsDraft bool `yaml:"-"`
 }
 
 func (vert *Vertical) GetDisplayName() string {
@@ -44,14 +62,28 @@ func (vert *Vertical) GetURLName() string {
 	return vert.URLName
 }
 
+func (vert *Vertical) GetIsDraft() bool {
+	return vert.IsDraft
+}
+
 func (vert *Vertical) GetExtraAttributes() map[string]string {
-	return map[string]string{}
+	attrs := vert.OutlineInfo.extraAttributes()
+	translationExtraAttributes(vert.Translated, attrs)
+	return attrs
 }
 
 func (vert *Vertical) GetBlocks() []ir.Block {
 	return blocksToIRBlocks(vert.Blocks)
 }
 
+func (vert *Vertical) GetAssets() []ir.Asset {
+	return assetsToIRAssets(vert.Assets)
+}
+
 func (vert *Vertical) SetUpdatedAt(updatedAt time.Time)  {
 	vert.UpdatedAt = updatedAt
 }
+
+func (vert *Vertical) SetCreatedAt(createdAt time.Time) {
+	vert.CreatedAt = createdAt
+}
@@ -1,8 +1,17 @@
 package extfmt
 
-import "github.com/exlskills/eocsutil/ir"
+import (
+	"github.com/exlskills/eocsutil/diagnostics"
+	"github.com/exlskills/eocsutil/ir"
+)
 
 type ExtFmt interface {
 	Import(fromUri string) (toIntermediateRepresentation ir.Course, err error)
 	Export(fromIntermediateRepresentation ir.Course, toUri string, forceExport bool) (err error)
 }
+
+// Verifier is implemented by the formats that can report all of the problems of a course rather than only the first one
+// that fails the import
+type Verifier interface {
+	Verify(fromUri string) ([]diagnostics.Diagnostic, error)
+}
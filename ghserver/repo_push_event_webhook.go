@@ -9,9 +9,11 @@ import (
 	"github.com/exlskills/eocsutil/gitutils"
 	"github.com/exlskills/eocsutil/smtputils"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"html"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -22,6 +24,14 @@ const (
 
 var failedSubject = config.Cfg().ServerNickname + ": Course Load Failed"
 var okSubject = config.Cfg().ServerNickname + ": Course Load Processed Successfully"
+var dryRunSubject = config.Cfg().ServerNickname + ": Course Load Dry Run"
+
+// isDryRunRequest checks the `dry_run` query flag, which makes the webhook report what the load would change instead
+// of loading the course
+func isDryRunRequest(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return dryRun
+}
 
 func repoPushEventWebhookLauncher(w http.ResponseWriter, r *http.Request) {
 	Log.Debug("In repoPushEventWebhookLauncher")
@@ -32,7 +42,7 @@ func repoPushEventWebhookLauncher(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.JSONInternalError(w, "Invalid Request", "")
 		return
 	}
-	go repoPushEventWebhookProcessor(reqObj, asyncMode)
+	go repoPushEventWebhookProcessor(reqObj, asyncMode, isDryRunRequest(r))
 	jsonhttp.JSONSuccess(w, nil, "Ack Receipt")
 	return
 }
@@ -46,7 +56,7 @@ func repoPushEventWebhook(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.JSONInternalError(w, "Invalid Request", "")
 		return
 	}
-	message, err := repoPushEventWebhookProcessor(reqObj, syncMode)
+	message, err := repoPushEventWebhookProcessor(reqObj, syncMode, isDryRunRequest(r))
 	if err != nil {
 		jsonhttp.JSONInternalError(w, message, "")
 	} else {
@@ -55,7 +65,7 @@ func repoPushEventWebhook(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-func repoPushEventWebhookProcessor(reqObj ghmodels.RepoPushEventRequest, mode int) (message string, err error) {
+func repoPushEventWebhookProcessor(reqObj ghmodels.RepoPushEventRequest, mode int, dryRun bool) (message string, err error) {
 
 	if len(reqObj.Ref) < 11 || !strings.HasPrefix(reqObj.Ref, "refs/heads/") {
 		Log.Info("Skipping push on empty or invalid ref: ", reqObj.Ref)
@@ -152,7 +162,26 @@ func repoPushEventWebhookProcessor(reqObj ghmodels.RepoPushEventRequest, mode in
 	courseDir := unzippedFilePaths[0]
 	*/
 
-	err = eocs.NewEOCSFormat().Push(rootDir, config.Cfg().GHServerMongoURI, true)
+	eocsFmt := eocs.NewEOCSFormat()
+	eocsFmt.IncrementalPush = config.Cfg().GHIncrementalPush
+	eocsFmt.PruneOrphans = config.Cfg().GHPruneOrphans
+	eocsFmt.IncludeDrafts = config.Cfg().GHIncludeDrafts
+	if dryRun {
+		report, err := eocsFmt.DryRunPush(rootDir, config.Cfg().GHServerMongoURI, true)
+		if err != nil {
+			Log.Errorf("Course push dry run failed: %s", err.Error())
+			if mode == asyncMode {
+				errEmailText := fmt.Sprintf(loadHeaderString+"<br> Error Converting Course<br>%v", err)
+				smtputils.SendEmail(reqObj.HeadCommit.Author.Email, failedSubject, errEmailText)
+			}
+			return "An error occurred importing the course", err
+		}
+		if mode == asyncMode {
+			smtputils.SendEmail(reqObj.HeadCommit.Author.Email, dryRunSubject, loadHeaderString+"<br><pre>"+html.EscapeString(report)+"</pre>")
+		}
+		return report, nil
+	}
+	err = eocsFmt.Push(rootDir, config.Cfg().GHServerMongoURI, true)
 	if err != nil {
 		Log.Errorf("Course push failed: %s", err.Error())
 		if mode == asyncMode {
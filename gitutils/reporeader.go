@@ -44,13 +44,16 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 	}
 
 	courseUpdatedAt := time.Time{}
+	courseCreatedAt := time.Time{}
 	start := time.Now()
 
 	for _, chapter := range course.GetChapters() {
 		chapUpdatedAt := time.Time{}
+		chapCreatedAt := time.Time{}
 
 		for _, sequential := range chapter.GetSequentials() {
 			seqUpdatedAt := time.Time{}
+			seqCreatedAt := time.Time{}
 
 			for _, vert := range sequential.GetVerticals() {
 				Log.Debug("Vertical URL ", vert.GetURLName())
@@ -88,6 +91,8 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 						Log.Errorf("Local Git Commits Issue for %s %v", fileNameInGit, err)
 						return err
 					}
+					// The commits come latest first, so the loop runs through the whole history of the file to get to the
+					// first commit for createdAt
 					for {
 						commit, err := commitsIter.Next()
 						if err != nil {
@@ -99,11 +104,8 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 						if updatedAt.IsZero() || updatedAt.Before(commit.Author.When) {
 							updatedAt = commit.Author.When
 						}
-						Log.Debugf("File Last Committed At %v", updatedAt)
-
-						// Uncomment this to continue looping and get createdAt from the 1st commit
-						break
 					}
+					Log.Debugf("File First Committed At %v, Last Committed At %v", createdAt, updatedAt)
 
 					return nil
 				})
@@ -115,7 +117,10 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 				Log.Debugf("Vertical UpdatedAt %s", updatedAt)
 
 				if !createdAt.IsZero() {
-					// Future, see the comment above
+					vert.SetCreatedAt(createdAt)
+					if seqCreatedAt.IsZero() || seqCreatedAt.After(createdAt) {
+						seqCreatedAt = createdAt
+					}
 				}
 
 				if !updatedAt.IsZero() {
@@ -127,6 +132,12 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 			} // On Verticals of the Sequential
 
 			Log.Debugf("Sequential UpdatedAt %s", seqUpdatedAt)
+			if !seqCreatedAt.IsZero() {
+				sequential.SetCreatedAt(seqCreatedAt)
+				if chapCreatedAt.IsZero() || chapCreatedAt.After(seqCreatedAt) {
+					chapCreatedAt = seqCreatedAt
+				}
+			}
 			if !seqUpdatedAt.IsZero() {
 				sequential.SetUpdatedAt(seqUpdatedAt)
 				if chapUpdatedAt.IsZero() || chapUpdatedAt.Before(seqUpdatedAt) {
@@ -136,6 +147,12 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 		} // On Sequentials of the Chapter
 
 		Log.Debugf("Chapter UpdatedAt %s", chapUpdatedAt)
+		if !chapCreatedAt.IsZero() {
+			chapter.SetCreatedAt(chapCreatedAt)
+			if courseCreatedAt.IsZero() || courseCreatedAt.After(chapCreatedAt) {
+				courseCreatedAt = chapCreatedAt
+			}
+		}
 		if !chapUpdatedAt.IsZero() {
 			chapter.SetUpdatedAt(chapUpdatedAt)
 			if courseUpdatedAt.IsZero() || courseUpdatedAt.Before(chapUpdatedAt) {
@@ -148,6 +165,9 @@ func SetCourseComponentsTimestamps(repoPath string, course ir.Course) (err error
 	if !courseUpdatedAt.IsZero() {
 		course.SetContentUpdatedAt(courseUpdatedAt)
 	}
+	if !courseCreatedAt.IsZero() {
+		course.SetContentCreatedAt(courseCreatedAt)
+	}
 
 	elapsed := time.Since(start)
 	Log.Infof("Git Commits Loop Process took %s", elapsed)
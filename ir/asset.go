@@ -0,0 +1,8 @@
+package ir
+
+// Asset is a file that the blocks of a course reference, e.g., an image
+type Asset interface {
+	// GetPath is the path of the file within the assets of the course or vertical, with forward slashes
+	GetPath() string
+	GetContents() ([]byte, error)
+}
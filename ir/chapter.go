@@ -6,6 +6,8 @@ type Chapter interface {
 	GetDisplayName() string
 	GetURLName() string
 	GetExtraAttributes() map[string]string
+	GetIsDraft() bool
 	GetSequentials() []Sequential
 	SetUpdatedAt(updatedAt time.Time)
+	SetCreatedAt(createdAt time.Time)
 }
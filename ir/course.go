@@ -11,5 +11,7 @@ type Course interface {
 	GetLanguage() string
 	GetExtraAttributes() map[string]string
 	GetChapters() []Chapter
+	GetAssets() []Asset
 	SetContentUpdatedAt(updatedAt time.Time)
+	SetContentCreatedAt(createdAt time.Time)
 }
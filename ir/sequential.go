@@ -7,7 +7,9 @@ type Sequential interface {
 	GetURLName() string
 	GetIsGraded() bool
 	GetAssignmentType() string
+	GetIsDraft() bool
 	GetExtraAttributes() map[string]string
 	GetVerticals() []Vertical
 	SetUpdatedAt(updatedAt time.Time)
+	SetCreatedAt(createdAt time.Time)
 }
@@ -6,6 +6,9 @@ type Vertical interface {
 	GetDisplayName() string
 	GetURLName() string
 	GetExtraAttributes() map[string]string
+	GetIsDraft() bool
 	GetBlocks() []Block
+	GetAssets() []Asset
 	SetUpdatedAt(updatedAt time.Time)
+	SetCreatedAt(createdAt time.Time)
 }
@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"github.com/exlskills/eocsutil/config"
+	"github.com/exlskills/eocsutil/diagnostics"
 	"github.com/exlskills/eocsutil/eocs"
 	"github.com/exlskills/eocsutil/eocsuri"
 	"github.com/exlskills/eocsutil/extfmt"
 	"github.com/exlskills/eocsutil/ghserver"
 	"github.com/exlskills/eocsutil/gitutils"
+	"github.com/exlskills/eocsutil/ir"
 	"github.com/exlskills/eocsutil/mdutils"
 	"github.com/exlskills/eocsutil/olx"
 	"github.com/exlskills/eocsutil/pdf"
@@ -28,23 +30,42 @@ var (
 	convertFromURI    = convertCmd.Flag("from-uri", "The URI to the source").Required().String()
 	convertToFormat   = convertCmd.Flag("to-format", "The destination format to convert to").Required().String()
 	convertToURI      = convertCmd.Flag("to-uri", "The destination URI").Required().String()
+	pushIncremental   = convertCmd.Flag("incremental", "When pushing to MongoDB, only write the records that changed since the last push or that are missing from the database").Default("false").Bool()
+	pushPrune         = convertCmd.Flag("prune", "When pushing to MongoDB, remove the records and search docs that are no longer part of the course").Default("false").Bool()
+	pushESReindex     = convertCmd.Flag("es-reindex", "When pushing to MongoDB, rebuild the Elasticsearch index with the current mapping before loading").Default("false").Bool()
+	pushDryRun        = convertCmd.Flag("dry-run", "When pushing to MongoDB, only print what the push would change without writing anything").Default("false").Bool()
+	convertWriteIDs   = convertCmd.Flag("write-ids", "Persist the IDs assigned during an EOCS import into the source index.yaml files").Default("false").Bool()
+	includeDrafts     = convertCmd.Flag("include-drafts", "Include the drafts of the course when pushing to MongoDB or exporting to PDF").Default("false").Bool()
+	convertCourseID   = convertCmd.Flag("course-id", "The ID of the course to read when --from-uri is a MongoDB URI").String()
 	verifyCmd         = kingpin.Command("verify", "Check that a course conforms to a supported format")
 	verifyFormat      = verifyCmd.Flag("format", "The format to which the course should conform to").Default("eocs").String()
 	verifyURI         = verifyCmd.Flag("uri", "The URI of the source of the course").Required().String()
+	verifyWriteIDs    = verifyCmd.Flag("write-ids", "Persist the IDs assigned during an EOCS import into the source index.yaml files").Default("false").Bool()
+	verifyOutput      = verifyCmd.Flag("output", "The format of the report of the problems found: text, json, github (Actions annotations) or checkstyle").Default(diagnostics.FormatText).Enum(diagnostics.Formats...)
+	renumberCmd       = kingpin.Command("renumber", "Rewrite the numeric prefixes of the directories of an EOCS course to a clean sequence")
+	renumberURI       = renumberCmd.Flag("uri", "The URI of the EOCS course").Required().String()
 )
 
 var Log = config.Cfg().GetLogger()
 
+// exitCode is what the program exits with once it has run
+var exitCode = 0
+
+var eocsFmt = eocs.NewEOCSFormat()
+
+var pdfFmt = pdf.NewPDFExtFmt()
+
 func init() {
-	extfmt.RegisterExtFmt("eocs", eocs.NewEOCSFormat())
+	extfmt.RegisterExtFmt("eocs", eocsFmt)
 	extfmt.RegisterExtFmt("olx", olx.NewOLXExtFmt())
-	extfmt.RegisterExtFmt("pdf", pdf.NewPDFExtFmt())
+	extfmt.RegisterExtFmt("pdf", pdfFmt)
 }
 
 func main() {
 	// Do this to ensure that our on exit traps work
 	run()
 	time.Sleep(time.Second * 1)
+	os.Exit(exitCode)
 }
 
 func run() {
@@ -55,8 +76,29 @@ func run() {
 	kingpin.CommandLine.Help = "EXL Open Courseware Standard - Utilities"
 	switch kingpin.Parse() {
 	case "convert":
-		if strings.HasPrefix(*convertToURI, "mongodb://") {
-			err := eocs.NewEOCSFormat().Push(*convertFromURI, *convertToURI, false)
+		eocsFmt.WriteIDs = *convertWriteIDs
+		eocsFmt.IncludeDrafts = *includeDrafts
+		pdfFmt.IncludeDrafts = *includeDrafts
+		if *pushDryRun && !eocs.IsPushURI(*convertToURI) {
+			Log.Errorf("--dry-run is only valid when pushing to MongoDB or file+json, got the destination %s", *convertToURI)
+			exitCode = 1
+			return
+		}
+		if eocs.IsPushURI(*convertToURI) {
+			eocsFmt.IncrementalPush = *pushIncremental
+			eocsFmt.PruneOrphans = *pushPrune
+			eocsFmt.ReindexSearch = *pushESReindex
+			if *pushDryRun {
+				report, err := eocsFmt.DryRunPush(*convertFromURI, *convertToURI, false)
+				if err != nil {
+					Log.Errorf("Course push dry run failed: %s", err.Error())
+					exitCode = 1
+					return
+				}
+				fmt.Print(report)
+				return
+			}
+			err := eocsFmt.Push(*convertFromURI, *convertToURI, false)
 			if err != nil {
 				Log.Errorf("Course push failed: %s", err.Error())
 				return
@@ -64,18 +106,20 @@ func run() {
 			return
 		}
 
-		// This is non-MongoDB only flow below !!!!!!!!!!!! See eocs/eocs.go for MongoDB logic
+		// This is non-MongoDB (and non-file+json) only flow below !!!!!!!!!!!! See eocs/eocs.go for MongoDB logic
 		Log.Info("Importing course for conversion ...")
-		ir, err := getExtFmtF(*convertFromFormat).Import(verifyAndCleanURIF(*convertFromURI))
+		ir, err := importCourseForConversion()
 		if err != nil {
 			Log.Errorf("Course import failed with: %s", err.Error())
 			return
 		}
 		Log.Info("Successfully imported course %s for conversion, now exporting ...", ir.GetDisplayName())
 
-		err = gitutils.SetCourseComponentsTimestamps(*convertFromURI, ir)
-		if err != nil {
-			Log.Info("Git reader failed - Timestamps will not be assigned")
+		if !strings.HasPrefix(*convertFromURI, "mongodb://") {
+			err = gitutils.SetCourseComponentsTimestamps(*convertFromURI, ir)
+			if err != nil {
+				Log.Warnf("Git reader failed - Timestamps will not be assigned: %s", err.Error())
+			}
 		}
 
 		err = getExtFmtF(*convertToFormat).Export(ir, verifyAndCleanURIF(*convertToURI), *convertForce)
@@ -86,12 +130,39 @@ func run() {
 		Log.Infof("Successfully exported course: %s", ir.GetDisplayName())
 	case "verify":
 		Log.Info("Importing course for verification ...")
-		ir, err := getExtFmtF(*verifyFormat).Import(verifyAndCleanURIF(*verifyURI))
+		eocsFmt.WriteIDs = *verifyWriteIDs
+		uri := verifyAndCleanURIF(*verifyURI)
+		diags, err := verifyCourse(uri)
 		if err != nil {
 			Log.Errorf("Course import verification failed with: %s", err.Error())
+			exitCode = 1
+			return
+		}
+		rootDir, err := eocsuri.GetAbsolutePathFromFileURI(uri)
+		if err != nil {
+			Log.Errorf("Course import verification failed with: %s", err.Error())
+			exitCode = 1
+			return
+		}
+		err = diagnostics.Write(os.Stdout, *verifyOutput, rootDir, diags)
+		if err != nil {
+			Log.Errorf("Writing the verification report failed with: %s", err.Error())
+			exitCode = 1
+			return
+		}
+		// Only the errors fail the verification, the warnings are for a look
+		if diagnostics.HasErrors(diags) {
+			exitCode = 1
+		}
+		return
+	case "renumber":
+		err := eocsFmt.Renumber(verifyAndCleanURIF(*renumberURI))
+		if err != nil {
+			Log.Errorf("Course renumbering failed with: %s", err.Error())
+			exitCode = 1
 			return
 		}
-		Log.Infof("Successfully verified course: %s", ir.GetDisplayName())
+		Log.Info("Successfully renumbered the course")
 		return
 	case "serve-gh-hook":
 		Log.Info("Serve GitHub Hooks ...")
@@ -102,6 +173,29 @@ func run() {
 	}
 }
 
+// importCourseForConversion reads the course either back out of MongoDB, which already carries its timestamps, or with
+// the --from-format importer
+func importCourseForConversion() (ir.Course, error) {
+	if strings.HasPrefix(*convertFromURI, "mongodb://") {
+		return eocsFmt.ImportFromMongo(*convertFromURI, *convertCourseID)
+	}
+	return getExtFmtF(*convertFromFormat).Import(verifyAndCleanURIF(*convertFromURI))
+}
+
+// verifyCourse reports the problems of the course, all of them for the formats that can, otherwise the one that fails
+// the import
+func verifyCourse(uri string) ([]diagnostics.Diagnostic, error) {
+	impl := getExtFmtF(*verifyFormat)
+	if verifier, ok := impl.(extfmt.Verifier); ok {
+		return verifier.Verify(uri)
+	}
+	_, err := impl.Import(uri)
+	if err != nil {
+		return []diagnostics.Diagnostic{diagnostics.Errorf("", 0, "import", "%s", err.Error())}, nil
+	}
+	return nil, nil
+}
+
 func getExtFmtF(key string) extfmt.ExtFmt {
 	impl := extfmt.GetImplementation(key)
 	if impl == nil {
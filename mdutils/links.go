@@ -0,0 +1,108 @@
+package mdutils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// The target of an inline link or image, e.g., ](assets/diagram.png "Diagram")
+	inlineLinkRegex = regexp.MustCompile(`\]\(\s*<?([^\s()<>]+)>?(?:\s+(?:"[^"]*"|'[^']*'|\([^)]*\)))?\s*\)`)
+	// The target of a link reference definition, e.g., [diagram]: assets/diagram.png
+	linkDefinitionRegex = regexp.MustCompile(`(?m)^ {0,3}\[[^\]]+\]:[ \t]*<?([^\s<>]+)>?`)
+	// The src or href of the HTML embedded in the markdown
+	htmlRefRegex    = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+	inlineCodeRegex = regexp.MustCompile("``[^\n]*?``|`[^`\n]+`")
+	schemeRegex     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// MapLinks replaces the target of each link and image of the markdown, including the src and href attributes of its
+// HTML, with what mapRef returns for it. The code blocks and spans are left alone
+func MapLinks(md string, mapRef func(ref string) string) string {
+	code := codeRanges(md)
+	var refs [][]int
+	for _, re := range []*regexp.Regexp{inlineLinkRegex, linkDefinitionRegex, htmlRefRegex} {
+		for _, m := range re.FindAllStringSubmatchIndex(md, -1) {
+			if !inRanges(code, m[2]) {
+				refs = append(refs, m[2:4])
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i][0] < refs[j][0] })
+	buf := strings.Builder{}
+	last := 0
+	for _, ref := range refs {
+		if ref[0] < last {
+			continue
+		}
+		buf.WriteString(md[last:ref[0]])
+		buf.WriteString(mapRef(md[ref[0]:ref[1]]))
+		last = ref[1]
+	}
+	buf.WriteString(md[last:])
+	return buf.String()
+}
+
+// IsRelativeLink returns whether the link target is a path relative to the file it is in, as opposed to a URL, an
+// absolute path or an anchor
+func IsRelativeLink(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "#") && !schemeRegex.MatchString(ref)
+}
+
+// SplitLink splits the link target into its path and its query and fragment, if any
+func SplitLink(ref string) (path, suffix string) {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
+
+// codeRanges returns the [start, end) offsets of the fenced code blocks and the code spans of the markdown
+func codeRanges(md string) (ranges [][2]int) {
+	fence := ""
+	fenceStart := 0
+	textStart := 0
+	addCodeSpans := func(start, end int) {
+		for _, m := range inlineCodeRegex.FindAllStringIndex(md[start:end], -1) {
+			ranges = append(ranges, [2]int{start + m[0], start + m[1]})
+		}
+	}
+	for offset := 0; offset < len(md); {
+		end := strings.IndexByte(md[offset:], '\n')
+		if end < 0 {
+			end = len(md)
+		} else {
+			end += offset + 1
+		}
+		line := strings.TrimLeft(md[offset:end], " ")
+		if fence == "" {
+			if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+				addCodeSpans(textStart, offset)
+				fence = line[:3]
+				fenceStart = offset
+			}
+		} else if strings.HasPrefix(line, fence) {
+			ranges = append(ranges, [2]int{fenceStart, end})
+			fence = ""
+			textStart = end
+		}
+		offset = end
+	}
+	if fence != "" {
+		// An unclosed fence runs to the end
+		ranges = append(ranges, [2]int{fenceStart, len(md)})
+	} else {
+		addCodeSpans(textStart, len(md))
+	}
+	return ranges
+}
+
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
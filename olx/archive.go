@@ -0,0 +1,165 @@
+package olx
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveCourseDirName is the top-level folder that Studio puts inside of the course export tarballs
+const archiveCourseDirName = "course"
+
+func isArchivePath(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// extractTarGz decompresses the archive into destDir, returning an error for any entry that would escape destDir
+func extractTarGz(archivePath, destDir string) (err error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fpath := filepath.Join(destDir, hdr.Name)
+		// Check for ZipSlip. More Info: http://bit.ly/2MsjAWE
+		if fpath != filepath.Clean(destDir) && !strings.HasPrefix(fpath, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("olx: illegal file path in archive: %s", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(fpath, 0775)
+			if err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			err = os.MkdirAll(filepath.Dir(fpath), 0775)
+			if err != nil {
+				return err
+			}
+			outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(outFile, tr)
+			// Close the file without defer to close before next iteration of loop
+			outFile.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			// Links and special files have no place in a course export, so skip them
+			Log.Debugf("Skipping archive entry %s of type %v", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+// findCourseRootDir returns the directory containing course.xml, which is either the extraction directory itself or the
+// single top-level folder inside of it (edX exports use `course/`)
+func findCourseRootDir(extractDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(extractDir, "course.xml")); err == nil {
+		return extractDir, nil
+	}
+	listing, err := ioutil.ReadDir(extractDir)
+	if err != nil {
+		return "", err
+	}
+	var dirs []string
+	for _, fi := range listing {
+		if fi.IsDir() {
+			dirs = append(dirs, fi.Name())
+		}
+	}
+	if len(dirs) != 1 {
+		return "", errors.New("olx: course archive must contain course.xml or a single top-level folder containing course.xml")
+	}
+	rootDir := filepath.Join(extractDir, dirs[0])
+	if _, err := os.Stat(filepath.Join(rootDir, "course.xml")); err != nil {
+		return "", errors.New(fmt.Sprintf("olx: course archive folder %s does not contain course.xml", dirs[0]))
+	}
+	return rootDir, nil
+}
+
+// writeTarGz archives the contents of srcDir into archivePath, placing everything under the topLevelDir folder
+func writeTarGz(srcDir, archivePath, topLevelDir string) (err error) {
+	err = os.MkdirAll(filepath.Dir(archivePath), 0775)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	// The writers are closed in order below, a failure on the way leaves a partial archive that is removed here
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(archivePath)
+		}
+	}()
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(topLevelDir, relPath))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		inFile, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer inFile.Close()
+		_, err = io.Copy(tw, inFile)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Closing the gzip writer writes the trailer, without which the archive is truncated
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	err = gzw.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,138 @@
+package olx
+
+import (
+	"github.com/exlskills/eocsutil/ir"
+	"github.com/exlskills/eocsutil/mdutils"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// The links of EOCS to the assets are relative: the course_image to the course folder, and the blocks to the directory
+// of their vertical, which has its own assets folder and is a few levels below the course folder
+const (
+	eocsAssetsPrefix = "assets/"
+	staticURLPrefix  = "/static/"
+)
+
+// Asset is a file of the static folder. The contents are held in memory, since an imported archive is only extracted
+// for the time of the import
+type Asset struct {
+	Path     string
+	Contents []byte
+}
+
+func (asset *Asset) GetPath() string {
+	return asset.Path
+}
+
+func (asset *Asset) GetContents() ([]byte, error) {
+	return asset.Contents, nil
+}
+
+func assetsToIRAssets(assets []*Asset) []ir.Asset {
+	irAssets := make([]ir.Asset, 0, len(assets))
+	for _, a := range assets {
+		irAssets = append(irAssets, a)
+	}
+	return irAssets
+}
+
+func irAssetsToAssets(assets []ir.Asset) ([]*Asset, error) {
+	olxAssets := make([]*Asset, 0, len(assets))
+	for _, a := range assets {
+		contents, err := a.GetContents()
+		if err != nil {
+			return nil, err
+		}
+		olxAssets = append(olxAssets, &Asset{Path: a.GetPath(), Contents: contents})
+	}
+	return olxAssets, nil
+}
+
+// readStaticAssets reads the files of the static folder. Those in a folder named after the url_name of a vertical
+// belong to that vertical, which is how the export writes them, the others belong to the course
+func (course *Course) readStaticAssets(rootDir string) error {
+	staticDir := filepath.Join(rootDir, staticDirName)
+	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		return nil
+	}
+	verts := make(map[string]*Vertical)
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			for _, vert := range seq.Verticals {
+				verts[vert.URLName] = vert
+			}
+		}
+	}
+	return filepath.Walk(staticDir, func(fsPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		relPath, err := filepath.Rel(staticDir, fsPath)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(fsPath)
+		if err != nil {
+			return err
+		}
+		asset := &Asset{Path: filepath.ToSlash(relPath), Contents: contents}
+		if parts := strings.SplitN(asset.Path, "/", 2); len(parts) == 2 {
+			if vert, ok := verts[parts[0]]; ok {
+				asset.Path = parts[1]
+				vert.Assets = append(vert.Assets, asset)
+				return nil
+			}
+		}
+		course.Assets = append(course.Assets, asset)
+		return nil
+	})
+}
+
+// writeStaticAssets writes the assets into the dir folder of the static folder
+func writeStaticAssets(rootDir, dir string, assets []*Asset) error {
+	for _, a := range assets {
+		assetPath := filepath.Join(staticDirName, dir, filepath.FromSlash(a.Path))
+		err := writeFile(rootDir, filepath.Dir(assetPath), filepath.Base(assetPath), a.Contents)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eocsLinksToStatic points the links of the markdown of an EOCS block to the assets at where the export puts them in
+// the static folder
+func eocsLinksToStatic(md, vertURLName string) string {
+	return mdutils.MapLinks(md, func(ref string) string {
+		if !mdutils.IsRelativeLink(ref) {
+			return ref
+		}
+		p, suffix := mdutils.SplitLink(ref)
+		p = path.Clean(p)
+		if strings.HasPrefix(p, eocsAssetsPrefix) {
+			return staticURLPrefix + vertURLName + "/" + strings.TrimPrefix(p, eocsAssetsPrefix) + suffix
+		}
+		// The course assets are reached by going up to the course folder
+		coursePath := p
+		for strings.HasPrefix(coursePath, "../") {
+			coursePath = strings.TrimPrefix(coursePath, "../")
+		}
+		if coursePath != p && strings.HasPrefix(coursePath, eocsAssetsPrefix) {
+			return staticURLPrefix + strings.TrimPrefix(coursePath, eocsAssetsPrefix) + suffix
+		}
+		return ref
+	})
+}
+
+// eocsCourseImageToStatic returns the name of the course image in the static folder, if it is one of the EOCS course
+// assets
+func eocsCourseImageToStatic(courseImage string) string {
+	return strings.TrimPrefix(courseImage, eocsAssetsPrefix)
+}
@@ -25,34 +25,63 @@ func appendIRBlocksToVertical(vert *Vertical, blocks []ir.Block) (err error) {
 	for _, b := range blocks {
 		newB := &Block{
 			XMLName:     xml.Name{Local: b.GetBlockType()},
+			ContentType: b.GetBlockType(),
 			URLName:     b.GetURLName(),
 			DisplayName: b.GetDisplayName(),
 			ExtraAttrs:  mapToXMLAttrs(b.GetExtraAttributes()),
 		}
-		var nodes []*BlockNode
-		olxStr, err := b.GetContentOLX()
-		Log.Info("olxStr: ", olxStr)
-		if err != nil {
-			if b.GetBlockType() == "html" {
+		switch newB.ContentType {
+		case "html":
+			olxStr, err := b.GetContentOLX()
+			if err != nil {
 				md, err := b.GetContentMD()
 				if err != nil {
 					// There's nothing else that we can do to recover at this point
 					return err
 				}
-				olxStr, err = mdutils.MakeHTML(md, "github")
-				Log.Info("md: ", olxStr)
+				olxStr, err = mdutils.MakeHTML(eocsLinksToStatic(md, vert.URLName), "github")
 				if err != nil {
 					return errors.New(fmt.Sprintf("olx: error converting md to OLX: %s", err.Error()))
 				}
+			}
+			// The HTML is written out as-is into the html/<url_name>.html file
+			newB.ContentTreeBytes = []byte(olxStr)
+		case "problem":
+			var olxStr string
+			md, err := b.GetContentMD()
+			if err == nil && md != "" {
+				md = eocsLinksToStatic(md, vert.URLName)
+				newB.Markdown = md
+				olxStr, err = mdutils.MakeOLX(md)
+				if err != nil {
+					return errors.New(fmt.Sprintf("olx: error converting problem md to OLX: %s", err.Error()))
+				}
 			} else {
+				// OLX problem blocks only carry the children of the <problem> element
+				olxStr, err = b.GetContentOLX()
+				if err != nil {
+					return err
+				}
+				olxStr = "<problem>" + olxStr + "</problem>"
+			}
+			probBlock := &Block{}
+			err = xml.Unmarshal([]byte(olxStr), probBlock)
+			if err != nil {
 				return err
 			}
+			newB.ContentTree = probBlock.ContentTree
+		default:
+			// Other block types are exported with their attributes and whatever OLX content they carry
+			olxStr, err := b.GetContentOLX()
+			if err == nil && olxStr != "" {
+				contentBlock := &Block{}
+				err = xml.Unmarshal([]byte("<"+newB.ContentType+">"+olxStr+"</"+newB.ContentType+">"), contentBlock)
+				if err != nil {
+					return err
+				}
+				newB.ContentTree = contentBlock.ContentTree
+			}
 		}
-		err = xml.Unmarshal([]byte(olxStr), &nodes)
-		if err != nil {
-			return err
-		}
-		newB.ContentTree = nodes
 		vert.Blocks = append(vert.Blocks, newB)
 	}
 	return nil
@@ -130,6 +159,27 @@ func (block *Block) resolveRecursive(rootDir string) (err error) {
 	return nil
 }
 
+// exportRecursive writes the block definition into <block type>/<url_name>.xml. HTML blocks keep their content in a separate
+// html/<url_name>.html file referenced by the `filename` attribute, which is what Studio expects
+func (block *Block) exportRecursive(rootDir string) (err error) {
+	blockFile := &Block{
+		XMLName:     block.XMLName,
+		DisplayName: block.DisplayName,
+		Markdown:    block.Markdown,
+		ExtraAttrs:  block.ExtraAttrs,
+		ContentTree: block.ContentTree,
+	}
+	if block.XMLName.Local == "html" {
+		blockFile.Filename = block.URLName
+		blockFile.ContentTree = nil
+		err = writeFile(rootDir, htmlDirName, urlNameToHTMLFileName(block.URLName), block.ContentTreeBytes)
+		if err != nil {
+			return err
+		}
+	}
+	return writeXMLFile(rootDir, block.XMLName.Local, block.URLName, blockFile)
+}
+
 func (block *Block) GetDisplayName() string {
 	return block.DisplayName
 }
@@ -166,4 +216,3 @@ func (block *Block) GetContentOLX() (string, error) {
 func (block *Block) GetExtraAttributes() map[string]string {
 	return xmlAttrsToMap(block.ExtraAttrs)
 }
-
@@ -26,6 +26,7 @@ func appendIRChaptersToCourse(course *Course, chaps []ir.Chapter) (err error) {
 			URLName:     c.GetURLName(),
 			DisplayName: c.GetDisplayName(),
 			ExtraAttrs:  mapToXMLAttrs(c.GetExtraAttributes()),
+			IsDraft:     c.GetIsDraft(),
 		}
 		err = appendIRSequentialsToChapter(newC, c.GetSequentials())
 		if err != nil {
@@ -42,7 +43,9 @@ type Chapter struct {
 	DisplayName string        `xml:"display_name,attr"`
 	Sequentials []*Sequential `xml:"sequential"`
 	ExtraAttrs  []xml.Attr    `xml:",any,attr"`
-	UpdatedAt   time.Time
+	UpdatedAt   time.Time     `xml:"-"`
+	CreatedAt   time.Time     `xml:"-"`
+	IsDraft     bool          `xml:"-"`
 }
 
 func (chap *Chapter) resolveRecursive(rootDir string) (err error) {
@@ -58,7 +61,9 @@ func (chap *Chapter) resolveRecursive(rootDir string) (err error) {
 		}
 		chap.DisplayName = fullChap.DisplayName
 		chap.Sequentials = fullChap.Sequentials
+		chap.ExtraAttrs = fullChap.ExtraAttrs
 	}
+	chap.ExtraAttrs, chap.IsDraft, _ = takeDraftAttrs(chap.ExtraAttrs)
 	if chap.DisplayName == "" {
 		return errors.New(fmt.Sprintf("invalid chapter: %s", chap.URLName))
 	}
@@ -71,6 +76,21 @@ func (chap *Chapter) resolveRecursive(rootDir string) (err error) {
 	return nil
 }
 
+func (chap *Chapter) exportRecursive(rootDir string) (err error) {
+	chapNode := &BlockNode{
+		XMLName: xml.Name{Local: "chapter"},
+		Attrs:   append(append([]xml.Attr{newXMLAttr("display_name", chap.DisplayName)}, staffOnlyAttrs(chap.IsDraft)...), chap.ExtraAttrs...),
+	}
+	for _, seq := range chap.Sequentials {
+		chapNode.Nodes = append(chapNode.Nodes, newURLNamePointer("sequential", seq.URLName))
+		err = seq.exportRecursive(rootDir)
+		if err != nil {
+			return err
+		}
+	}
+	return writeXMLFile(rootDir, chapterDirName, chap.URLName, chapNode)
+}
+
 func (chap *Chapter) GetDisplayName() string {
 	return chap.DisplayName
 }
@@ -83,10 +103,18 @@ func (chap *Chapter) GetExtraAttributes() map[string]string {
 	return xmlAttrsToMap(chap.ExtraAttrs)
 }
 
+func (chap *Chapter) GetIsDraft() bool {
+	return chap.IsDraft
+}
+
 func (chap *Chapter) GetSequentials() []ir.Sequential {
 	return sequentialsToIRSequentials(chap.Sequentials)
 }
 
 func (chap *Chapter) SetUpdatedAt(updatedAt time.Time) {
 	chap.UpdatedAt = updatedAt
-}
\ No newline at end of file
+}
+
+func (chap *Chapter) SetCreatedAt(createdAt time.Time) {
+	chap.CreatedAt = createdAt
+}
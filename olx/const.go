@@ -9,7 +9,6 @@ const (
 	htmlDirName        = "html"
 	infoDirName        = "info"
 	policiesDirName    = "policies"
-	problemsDirName    = "problems"
 	sequentialsDirName = "sequential"
 	staticDirName      = "static"
 	verticalsDirName   = "vertical"
@@ -44,6 +44,14 @@ func resolveCourseRecursive(rootDir string) (*Course, error) {
 			return nil, err
 		}
 	}
+	err = c.resolveDrafts(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	err = c.readStaticAssets(rootDir)
+	if err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -53,24 +61,57 @@ func exportCourseRecursive(course ir.Course, rootDir string) (err error) {
 		DisplayName: course.GetDisplayName(),
 		Org:         course.GetOrgName(),
 		CourseCode:  course.GetCourseCode(),
-		CourseImage: course.GetCourseImage(),
+		CourseImage: eocsCourseImageToStatic(course.GetCourseImage()),
 		Language:    course.GetLanguage(),
 		ExtraAttrs:  mapToXMLAttrs(course.GetExtraAttributes()),
 	}
 	Log.Info(`in exportCourseRecursive`)
+	courseFile.Assets, err = irAssetsToAssets(course.GetAssets())
+	if err != nil {
+		return err
+	}
 	err = appendIRChaptersToCourse(courseFile, course.GetChapters())
 	if err != nil {
 		return err
 	}
-	courseFileXML, err := xml.Marshal(courseFile)
+	// The root course.xml only points to the full course definition in course/<url_name>.xml
+	rootFileXML, err := xml.Marshal(&ExportCourseRootFile{
+		URLName: courseFile.URLName,
+		Org:     courseFile.Org,
+		Course:  courseFile.CourseCode,
+	})
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(filepath.Join(rootDir, "course.xml"), courseFileXML, 0755)
+	err = ioutil.WriteFile(filepath.Join(rootDir, "course.xml"), append(rootFileXML, '\n'), 0755)
 	if err != nil {
 		return err
 	}
-	return nil
+	return courseFile.exportRecursive(rootDir)
+}
+
+func (course *Course) exportRecursive(rootDir string) (err error) {
+	courseNode := &BlockNode{
+		XMLName: xml.Name{Local: "course"},
+		Attrs: append([]xml.Attr{
+			newXMLAttr("display_name", course.DisplayName),
+			newXMLAttr("course_image", course.CourseImage),
+			newXMLAttr("language", course.Language),
+		}, course.ExtraAttrs...),
+	}
+	course.setDraftParents()
+	for _, chap := range course.Chapters {
+		courseNode.Nodes = append(courseNode.Nodes, newURLNamePointer("chapter", chap.URLName))
+		err = chap.exportRecursive(rootDir)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeStaticAssets(rootDir, "", course.Assets)
+	if err != nil {
+		return err
+	}
+	return writeXMLFile(rootDir, courseDirName, course.URLName, courseNode)
 }
 
 type ExportCourseRootFile struct {
@@ -81,16 +122,18 @@ type ExportCourseRootFile struct {
 }
 
 type Course struct {
-	XMLName     xml.Name   `xml:"course"`
-	URLName     string     `xml:"url_name,attr"`
-	DisplayName string     `xml:"display_name,attr"`
-	Org         string     `xml:"org,attr"`
-	CourseCode  string     `xml:"course,attr"`
-	CourseImage string     `xml:"course_image,attr"`
-	Language    string     `xml:"language,attr"`
-	ExtraAttrs  []xml.Attr `xml:",any,attr"`
-	Chapters    []*Chapter `xml:"chapter"`
-	ContentUpdatedAt   time.Time
+	XMLName          xml.Name   `xml:"course"`
+	URLName          string     `xml:"url_name,attr"`
+	DisplayName      string     `xml:"display_name,attr"`
+	Org              string     `xml:"org,attr"`
+	CourseCode       string     `xml:"course,attr"`
+	CourseImage      string     `xml:"course_image,attr"`
+	Language         string     `xml:"language,attr"`
+	ExtraAttrs       []xml.Attr `xml:",any,attr"`
+	Chapters         []*Chapter `xml:"chapter"`
+	ContentUpdatedAt time.Time  `xml:"-"`
+	ContentCreatedAt time.Time  `xml:"-"`
+	Assets           []*Asset   `xml:"-"`
 }
 
 func (course *Course) GetDisplayName() string {
@@ -125,6 +168,14 @@ func (course *Course) GetChapters() []ir.Chapter {
 	return chaptersToIRChapters(course.Chapters)
 }
 
+func (course *Course) GetAssets() []ir.Asset {
+	return assetsToIRAssets(course.Assets)
+}
+
 func (course *Course) SetContentUpdatedAt(updatedAt time.Time) {
 	course.ContentUpdatedAt = updatedAt
-}
\ No newline at end of file
+}
+
+func (course *Course) SetContentCreatedAt(createdAt time.Time) {
+	course.ContentCreatedAt = createdAt
+}
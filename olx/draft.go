@@ -0,0 +1,161 @@
+package olx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// staffOnlyAttr is how OLX hides a chapter, sequential or vertical from the learners, which makes it a draft
+const staffOnlyAttr = "visible_to_staff_only"
+
+// The attributes of a vertical of the drafts folder that place it in its sequential
+const (
+	draftParentURLAttr = "parent_url"
+	draftIndexAttr     = "index_in_children_list"
+)
+
+// takeDraftAttrs removes the attributes that are carried by the IsDraft flag and the position of a draft, returning
+// whether the item is hidden from the learners along with the removed attributes
+func takeDraftAttrs(attrs []xml.Attr) (kept []xml.Attr, isStaffOnly bool, taken map[string]string) {
+	taken = make(map[string]string)
+	for _, a := range attrs {
+		switch a.Name.Local {
+		case staffOnlyAttr:
+			isStaffOnly = a.Value == "true"
+		case draftParentURLAttr, draftIndexAttr:
+			taken[a.Name.Local] = a.Value
+		default:
+			kept = append(kept, a)
+		}
+	}
+	return kept, isStaffOnly, taken
+}
+
+func staffOnlyAttrs(isDraft bool) []xml.Attr {
+	if !isDraft {
+		return nil
+	}
+	return []xml.Attr{newXMLAttr(staffOnlyAttr, "true")}
+}
+
+// draftParentURLName returns the url_name of the sequential that the parent_url of a draft points to, which is either
+// an i4x://<org>/<course>/sequential/<url_name> URL or a block-v1:...+type@sequential+block@<url_name> key
+func draftParentURLName(parentURL string) string {
+	name := parentURL[strings.LastIndex(parentURL, "/")+1:]
+	return name[strings.LastIndex(name, "@")+1:]
+}
+
+// resolveDrafts adds the verticals of the drafts folder to their sequentials as drafts. A draft of a published vertical
+// is an unpublished change of it, which is left out since the course has the published version
+func (course *Course) resolveDrafts(rootDir string) error {
+	draftsDir := filepath.Join(rootDir, draftsDirName)
+	listing, err := ioutil.ReadDir(filepath.Join(draftsDir, verticalsDirName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	seqs := make(map[string]*Sequential)
+	published := make(map[string]bool)
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			seqs[seq.URLName] = seq
+			for _, vert := range seq.Verticals {
+				published[vert.URLName] = true
+			}
+		}
+	}
+	type draft struct {
+		vert  *Vertical
+		seq   *Sequential
+		index int
+	}
+	var drafts []draft
+	for _, fi := range listing {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".xml" {
+			continue
+		}
+		vert := &Vertical{URLName: strings.TrimSuffix(fi.Name(), ".xml")}
+		if published[vert.URLName] {
+			Log.Infof("Leaving out the unpublished changes of vertical %s", vert.URLName)
+			continue
+		}
+		err = vert.resolveDraftRecursive(rootDir, draftsDir)
+		if err != nil {
+			return err
+		}
+		var position map[string]string
+		vert.ExtraAttrs, _, position = takeDraftAttrs(vert.ExtraAttrs)
+		vert.IsDraft = true
+		seq := seqs[draftParentURLName(position[draftParentURLAttr])]
+		if seq == nil {
+			return errors.New(fmt.Sprintf("olx: the parent %q of draft vertical %s is not a sequential of the course", position[draftParentURLAttr], vert.URLName))
+		}
+		index, err := strconv.Atoi(position[draftIndexAttr])
+		if err != nil {
+			index = len(seq.Verticals)
+		}
+		drafts = append(drafts, draft{vert: vert, seq: seq, index: index})
+	}
+	// Inserting in the order of the indexes puts each draft where it was among the children of the sequential
+	sort.SliceStable(drafts, func(i, j int) bool { return drafts[i].index < drafts[j].index })
+	for _, d := range drafts {
+		index := d.index
+		if index > len(d.seq.Verticals) {
+			index = len(d.seq.Verticals)
+		}
+		d.seq.Verticals = append(d.seq.Verticals, nil)
+		copy(d.seq.Verticals[index+1:], d.seq.Verticals[index:])
+		d.seq.Verticals[index] = d.vert
+	}
+	return nil
+}
+
+// resolveDraftRecursive reads a vertical of the drafts folder along with its blocks, taking the published version of
+// those that have no draft
+func (vert *Vertical) resolveDraftRecursive(rootDir, draftsDir string) error {
+	draftVertXML, err := ioutil.ReadFile(filepath.Join(draftsDir, verticalsDirName, urlNameToXMLFileName(vert.URLName)))
+	if err != nil {
+		return err
+	}
+	err = xml.Unmarshal(draftVertXML, vert)
+	if err != nil {
+		return err
+	}
+	if vert.DisplayName == "" {
+		return errors.New(fmt.Sprintf("invalid draft vertical: %s", vert.URLName))
+	}
+	for i, blk := range vert.Blocks {
+		blockDir := draftsDir
+		if _, err := os.Stat(filepath.Join(draftsDir, blk.XMLName.Local, urlNameToXMLFileName(blk.URLName))); os.IsNotExist(err) {
+			blockDir = rootDir
+		}
+		err = vert.Blocks[i].resolveRecursive(blockDir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setDraftParents points the draft verticals at their sequentials the way Studio does, for exporting them into the
+// drafts folder
+func (course *Course) setDraftParents() {
+	for _, chap := range course.Chapters {
+		for _, seq := range chap.Sequentials {
+			for i, vert := range seq.Verticals {
+				if vert.IsDraft {
+					vert.draftParentURL = fmt.Sprintf("i4x://%s/%s/sequential/%s", course.Org, course.CourseCode, seq.URLName)
+					vert.draftIndex = i
+				}
+			}
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/exlskills/eocsutil/config"
 	"github.com/exlskills/eocsutil/eocsuri"
 	"github.com/exlskills/eocsutil/ir"
+	"github.com/pkg/errors"
+	"io/ioutil"
 	"os"
 )
 
@@ -16,14 +18,33 @@ func NewOLXExtFmt() *OLX {
 type OLX struct {
 }
 
+// Import reads an OLX course from either a directory or a Studio-style .tar.gz/.tgz export archive
 func (o *OLX) Import(fromUri string) (toIntermediateRepresentation ir.Course, err error) {
 	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(fromUri)
 	if err != nil {
 		return nil, err
 	}
+	if isArchivePath(rootDir) {
+		extractDir, err := ioutil.TempDir("", "eocsutil-olx-import-")
+		if err != nil {
+			return nil, err
+		}
+		// The whole course is read into memory by resolveCourseRecursive, so the extracted files can be cleaned up right away
+		defer os.RemoveAll(extractDir)
+		Log.Infof("Extracting OLX course archive %s", rootDir)
+		err = extractTarGz(rootDir, extractDir)
+		if err != nil {
+			return nil, err
+		}
+		rootDir, err = findCourseRootDir(extractDir)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return resolveCourseRecursive(rootDir)
 }
 
+// Export writes the OLX course into a directory, or into a Studio-importable .tar.gz/.tgz archive if the URI names one
 func (o *OLX) Export(fromIntermediateRepresentation ir.Course, toUri string, forceExport bool) (err error) {
 	rootDir, err := eocsuri.GetAbsolutePathFromFileURI(toUri)
 	if err != nil {
@@ -35,9 +56,29 @@ func (o *OLX) Export(fromIntermediateRepresentation ir.Course, toUri string, for
 			return err
 		}
 	}
+	if isArchivePath(rootDir) {
+		return exportCourseArchive(fromIntermediateRepresentation, rootDir)
+	}
 	err = os.MkdirAll(rootDir, 0775)
 	if err != nil {
 		return err
 	}
 	return exportCourseRecursive(fromIntermediateRepresentation, rootDir)
 }
+
+func exportCourseArchive(course ir.Course, archivePath string) (err error) {
+	if _, err := os.Stat(archivePath); err == nil {
+		return errors.New("olx: course archive already exists, use --force to overwrite it")
+	}
+	exportDir, err := ioutil.TempDir("", "eocsutil-olx-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(exportDir)
+	err = exportCourseRecursive(course, exportDir)
+	if err != nil {
+		return err
+	}
+	Log.Infof("Writing OLX course archive %s", archivePath)
+	return writeTarGz(exportDir, archivePath, archiveCourseDirName)
+}
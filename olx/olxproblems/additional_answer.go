@@ -0,0 +1,6 @@
+package olxproblems
+
+type AdditionalAnswer struct {
+	Answer      string       `xml:"answer,attr"`
+	CorrectHint *CorrectHint `xml:"correcthint,omitempty"`
+}
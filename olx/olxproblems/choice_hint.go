@@ -2,5 +2,6 @@ package olxproblems
 
 type ChoiceHint struct {
 	Selected *bool  `xml:"selected,attr"`
+	Label    string `xml:"label,attr,omitempty"`
 	InnerXML string `xml:",innerxml"`
 }
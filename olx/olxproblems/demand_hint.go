@@ -1,5 +1,6 @@
 package olxproblems
 
+// DemandHint holds the hints of the problem in the order in which they are revealed
 type DemandHint struct {
-	Hint string `xml:"hint"`
+	Hints []string `xml:"hint"`
 }
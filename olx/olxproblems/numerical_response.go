@@ -0,0 +1,9 @@
+package olxproblems
+
+type NumericalResponse struct {
+	Label             ProblemLabel       `xml:"label"`
+	Answer            string             `xml:"answer,attr"`
+	ResponseParam     *ResponseParam     `xml:"responseparam,omitempty"`
+	AdditionalAnswers []AdditionalAnswer `xml:"additional_answer"`
+	CorrectHint       *CorrectHint       `xml:"correcthint,omitempty"`
+}
@@ -0,0 +1,7 @@
+package olxproblems
+
+type Option struct {
+	Correct    bool         `xml:"correct,attr"`
+	OptionHint []OptionHint `xml:"optionhint"`
+	InnerXML   string       `xml:",innerxml"`
+}
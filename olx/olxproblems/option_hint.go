@@ -0,0 +1,6 @@
+package olxproblems
+
+type OptionHint struct {
+	Label    string `xml:"label,attr,omitempty"`
+	InnerXML string `xml:",innerxml"`
+}
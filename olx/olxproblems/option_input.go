@@ -0,0 +1,5 @@
+package olxproblems
+
+type OptionInput struct {
+	Options []Option `xml:"option"`
+}
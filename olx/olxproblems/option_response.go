@@ -0,0 +1,6 @@
+package olxproblems
+
+type OptionResponse struct {
+	Label       ProblemLabel `xml:"label"`
+	OptionInput *OptionInput `xml:"optioninput"`
+}
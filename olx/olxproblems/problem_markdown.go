@@ -0,0 +1,372 @@
+package olxproblems
+
+import (
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// This is a Go port of the edX problem markdown dialect (see `markdownToXml` in showdownjs/olxutils.js) that produces the
+// Problem struct directly rather than going through the OLX XML. Text inside of fenced code blocks is never interpreted as
+// problem syntax, and inline code is respected when looking for the `>>label<<` delimiters.
+
+var (
+	mdFenceRegex              = regexp.MustCompile("^\\s*```")
+	mdSectionSeparatorRegex   = regexp.MustCompile(`^\s*---\s*$`)
+	mdHeaderUnderlineRegex    = regexp.MustCompile(`^==+\s*$`)
+	mdDemandHintRegex         = regexp.MustCompile(`^\s*\|\|(.*?)\|\|\s*$`)
+	mdChoiceRegex             = regexp.MustCompile(`^\s*\((.{0,3})\)\s*(.*)$`)
+	mdMultiLineChoiceRegex    = regexp.MustCompile(`^\s*\+\((.{0,3})\)\s*(.*)$`)
+	mdMultiLineChoiceEndRegex = regexp.MustCompile(`^\s*-\(.{0,3}\)-?\s*$`)
+	mdCheckboxRegex           = regexp.MustCompile(`^\s*\[(.?)\]\s*(.*)$`)
+	mdCompoundHintRegex       = regexp.MustCompile(`^\s*{{\s*\(\(.*?\)\).*?}}`)
+	mdAnswerRegex             = regexp.MustCompile(`^(s?)=\s*(.*)$`)
+	mdAdditionalAnswerRegex   = regexp.MustCompile(`^(or|not)=\s*(.*)$`)
+	mdHintRegex               = regexp.MustCompile(`\s*{{(.*?)}}`)
+	mdHintLabelRegex          = regexp.MustCompile(`^(.*?)::`)
+	mdSelectedHintRegex       = regexp.MustCompile(`(?is){\s*(?:s|selected):(.*?)}`)
+	mdUnselectedHintRegex     = regexp.MustCompile(`(?is){\s*(?:u|unselected):(.*?)}`)
+	mdOptionCorrectRegex      = regexp.MustCompile(`^\((.*?)\)$`)
+	mdNumberPrefixRegex       = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)`)
+	mdToleranceRegex          = regexp.MustCompile(`^(.*?)\+-\s*(.*?)$`)
+	mdWhitespaceRegex         = regexp.MustCompile(`\s+`)
+)
+
+var ErrNoProblemResponse = errors.New("olxproblems: problem markdown does not define a response (choices, checkboxes, a dropdown or an answer)")
+
+type mdHint struct {
+	text    string
+	hint    string
+	label   string
+	hasHint bool
+}
+
+// extractMDHint splits `text {{ label:: hint }}` into its parts
+func extractMDHint(text string) mdHint {
+	res := mdHint{text: text}
+	loc := mdHintRegex.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return res
+	}
+	res.text = text[:loc[0]] + text[loc[1]:]
+	res.hint = strings.TrimSpace(text[loc[2]:loc[3]])
+	res.hasHint = true
+	if lm := mdHintLabelRegex.FindStringSubmatch(res.hint); lm != nil {
+		res.hint = strings.TrimSpace(strings.Replace(res.hint, lm[0], "", 1))
+		res.label = strings.TrimSpace(lm[1])
+	}
+	return res
+}
+
+func isMDFence(line string) bool {
+	return mdFenceRegex.MatchString(line)
+}
+
+func splitMDSections(md string) [][]string {
+	var sections [][]string
+	var cur []string
+	inFence := false
+	for _, line := range strings.Split(md, "\n") {
+		if isMDFence(line) {
+			inFence = !inFence
+		} else if !inFence && mdSectionSeparatorRegex.MatchString(line) {
+			sections = append(sections, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, line)
+	}
+	return append(sections, cur)
+}
+
+// extractMDLabel returns the `>>question||description<<` label (without the description) and the text with the label removed
+func extractMDLabel(text string) (label string, rest string, found bool) {
+	start, end := -1, -1
+	inCode := false
+	for i := 0; i < len(text)-1; i++ {
+		if text[i] == '`' {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if start < 0 && text[i] == '>' && text[i+1] == '>' {
+			start = i
+			i++
+		} else if start >= 0 && text[i] == '<' && text[i+1] == '<' {
+			end = i
+			break
+		}
+	}
+	if start < 0 || end < 0 {
+		return "", text, false
+	}
+	label = strings.SplitN(text[start+2:end], "||", 2)[0]
+	return strings.TrimSpace(label), text[:start] + text[end+2:], true
+}
+
+type mdProblemParser struct {
+	prob        *Problem
+	demandHints []string
+}
+
+func parseProblemMD(md string) (*Problem, error) {
+	md = strings.Replace(md, "\r\n", "\n", -1)
+	p := &mdProblemParser{
+		prob: &Problem{XMLName: xml.Name{Local: "problem"}},
+	}
+	for _, section := range splitMDSections(md) {
+		if strings.TrimSpace(strings.Join(section, "")) == "" {
+			continue
+		}
+		p.parseSection(strings.Join(section, "\n"))
+	}
+	if p.prob.MultipleChoiceResponse == nil && p.prob.ChoiceResponse == nil && p.prob.StringResponse == nil &&
+		p.prob.OptionResponse == nil && p.prob.NumericalResponse == nil {
+		return nil, ErrNoProblemResponse
+	}
+	if len(p.demandHints) > 0 {
+		p.prob.DemandHint = &DemandHint{Hints: p.demandHints}
+	}
+	return p.prob, nil
+}
+
+func (p *mdProblemParser) parseSection(section string) {
+	label, section, _ := extractMDLabel(section)
+	var (
+		mc       *MultipleChoiceResponse
+		cr       *ChoiceResponse
+		sr       *StringResponse
+		opr      *OptionResponse
+		nr       *NumericalResponse
+		mlChoice *strings.Builder
+		mlCorr   bool
+		inFence  bool
+	)
+	finishMultiLineChoice := func() {
+		if mlChoice == nil {
+			return
+		}
+		mc.ChoiceGroup.Choices = append(mc.ChoiceGroup.Choices, newMDChoice(strings.TrimSpace(mlChoice.String()), mlCorr))
+		mlChoice = nil
+	}
+	lines := strings.Split(section, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if isMDFence(line) {
+			inFence = !inFence
+		}
+		if inFence || isMDFence(line) {
+			if mlChoice != nil {
+				mlChoice.WriteString("\n" + line)
+			}
+			continue
+		}
+		// Extended hints {{ ... }} may span multiple lines, so pull them onto a single line
+		for strings.Contains(line, "{{") && !strings.Contains(line[strings.LastIndex(line, "{{"):], "}}") && i+1 < len(lines) {
+			i++
+			line += " " + strings.TrimLeft(lines[i], " \t")
+		}
+		if m := mdMultiLineChoiceRegex.FindStringSubmatch(line); m != nil {
+			finishMultiLineChoice()
+			if mc == nil {
+				mc = newMDMultipleChoiceResponse()
+			}
+			mlChoice = &strings.Builder{}
+			mlChoice.WriteString(m[2])
+			mlCorr = strings.ContainsAny(m[1], "xX")
+			continue
+		}
+		if mlChoice != nil {
+			if mdMultiLineChoiceEndRegex.MatchString(line) {
+				finishMultiLineChoice()
+			} else {
+				mlChoice.WriteString("\n" + line)
+			}
+			continue
+		}
+		// Dropdowns are either `[[ a, (b), c ]]` on one line or `[[` followed by one option per line up to `]]`. This
+		// has to come before the multiple choice handling, since `(b)` on its own line looks like a choice
+		if strings.HasPrefix(strings.TrimSpace(line), "[[") {
+			optLines := []string{strings.TrimPrefix(strings.TrimSpace(line), "[[")}
+			for !strings.Contains(optLines[len(optLines)-1], "]]") && i+1 < len(lines) {
+				i++
+				optLines = append(optLines, lines[i])
+			}
+			last := optLines[len(optLines)-1]
+			if idx := strings.Index(last, "]]"); idx >= 0 {
+				optLines[len(optLines)-1] = last[:idx]
+			}
+			if opr == nil {
+				opr = &OptionResponse{OptionInput: &OptionInput{}}
+			}
+			opr.OptionInput.Options = append(opr.OptionInput.Options, newMDOptions(optLines)...)
+			continue
+		}
+		switch {
+		case mdHeaderUnderlineRegex.MatchString(line):
+			continue
+		case mdDemandHintRegex.MatchString(line):
+			p.demandHints = append(p.demandHints, strings.TrimSpace(mdDemandHintRegex.FindStringSubmatch(line)[1]))
+		case mdChoiceRegex.MatchString(line):
+			m := mdChoiceRegex.FindStringSubmatch(line)
+			if mc == nil {
+				mc = newMDMultipleChoiceResponse()
+			}
+			mc.ChoiceGroup.Choices = append(mc.ChoiceGroup.Choices, newMDChoice(strings.TrimSpace(m[2]), strings.ContainsAny(m[1], "xX")))
+		case mdCompoundHintRegex.MatchString(line):
+			// Compound checkbox hints are not carried by the Problem model
+			continue
+		case mdCheckboxRegex.MatchString(line):
+			m := mdCheckboxRegex.FindStringSubmatch(line)
+			if cr == nil {
+				cr = &ChoiceResponse{CheckboxGroup: &CheckboxGroup{}}
+			}
+			cr.CheckboxGroup.Choices = append(cr.CheckboxGroup.Choices, newMDCheckboxChoice(strings.TrimSpace(m[2]), strings.ContainsAny(m[1], "xX")))
+		case sr == nil && nr == nil && mdAnswerRegex.MatchString(line):
+			m := mdAnswerRegex.FindStringSubmatch(line)
+			h := extractMDHint(m[2])
+			// As in edX, `= 5` is a numerical answer, while `s= 5` forces a string answer
+			if m[1] == "" && isMDNumericalAnswer(strings.TrimSpace(h.text)) {
+				nr = newMDNumericalResponse(strings.TrimSpace(h.text), h)
+				continue
+			}
+			sr = &StringResponse{
+				Answer: strings.TrimSpace(h.text),
+				Type:   "ci",
+			}
+			if strings.HasPrefix(sr.Answer, "|") {
+				sr.Answer = strings.TrimSpace(sr.Answer[1:])
+				sr.Type = "ci regexp"
+			}
+		case nr != nil && mdAdditionalAnswerRegex.MatchString(line):
+			m := mdAdditionalAnswerRegex.FindStringSubmatch(line)
+			h := extractMDHint(m[2])
+			answer := strings.TrimSpace(h.text)
+			// Only plain numbers are accepted as additional numerical answers, so ranges and tolerances are skipped
+			if m[1] != "or" || !mdNumberPrefixRegex.MatchString(answer) || isMDRangeAnswer(answer) || mdToleranceRegex.MatchString(answer) {
+				continue
+			}
+			aa := AdditionalAnswer{Answer: answer}
+			if h.hasHint {
+				aa.CorrectHint = &CorrectHint{Label: h.label, InnerXML: h.hint}
+			}
+			nr.AdditionalAnswers = append(nr.AdditionalAnswers, aa)
+		case sr != nil && mdAdditionalAnswerRegex.MatchString(line):
+			// or= / not= answers are not carried by the StringResponse
+			continue
+		}
+	}
+	finishMultiLineChoice()
+	if mc != nil && p.prob.MultipleChoiceResponse == nil {
+		mc.Label = ProblemLabel{InnerXML: label}
+		p.prob.MultipleChoiceResponse = mc
+	}
+	if cr != nil && p.prob.ChoiceResponse == nil {
+		cr.Label = ProblemLabel{InnerXML: label}
+		p.prob.ChoiceResponse = cr
+	}
+	if sr != nil && p.prob.StringResponse == nil {
+		sr.Label = ProblemLabel{InnerXML: label}
+		p.prob.StringResponse = sr
+	}
+	if opr != nil && p.prob.OptionResponse == nil {
+		opr.Label = ProblemLabel{InnerXML: label}
+		p.prob.OptionResponse = opr
+	}
+	if nr != nil && p.prob.NumericalResponse == nil {
+		nr.Label = ProblemLabel{InnerXML: label}
+		p.prob.NumericalResponse = nr
+	}
+}
+
+func newMDMultipleChoiceResponse() *MultipleChoiceResponse {
+	return &MultipleChoiceResponse{ChoiceGroup: &ChoiceGroup{Type: "MultipleChoice"}}
+}
+
+func newMDChoice(value string, correct bool) Choice {
+	c := Choice{Correct: correct, InnerXML: value}
+	if h := extractMDHint(value); h.hasHint {
+		c.InnerXML = strings.TrimSpace(h.text)
+		c.ChoiceHint = append(c.ChoiceHint, ChoiceHint{Label: h.label, InnerXML: h.hint})
+	}
+	return c
+}
+
+// newMDCheckboxChoice handles the `[x] text {{ {selected: hint}, {unselected: hint} }}` checkbox syntax. As in edX, the
+// hint is only taken out of the text when the selected/unselected syntax is used
+func newMDCheckboxChoice(value string, correct bool) Choice {
+	c := Choice{Correct: correct, InnerXML: value}
+	h := extractMDHint(value)
+	if !h.hasHint {
+		return c
+	}
+	inner := "{" + h.hint + "}"
+	if m := mdSelectedHintRegex.FindStringSubmatch(inner); m != nil {
+		selected := true
+		c.ChoiceHint = append(c.ChoiceHint, ChoiceHint{Selected: &selected, InnerXML: strings.TrimSpace(m[1])})
+	}
+	if m := mdUnselectedHintRegex.FindStringSubmatch(inner); m != nil {
+		selected := false
+		c.ChoiceHint = append(c.ChoiceHint, ChoiceHint{Selected: &selected, InnerXML: strings.TrimSpace(m[1])})
+	}
+	if len(c.ChoiceHint) > 0 {
+		c.InnerXML = strings.TrimSpace(h.text)
+	}
+	return c
+}
+
+// newMDOptions builds the dropdown options out of the lines between `[[` and `]]`. A single line is comma separated,
+// otherwise there is one option per line. The correct option is wrapped in parentheses
+func newMDOptions(optLines []string) []Option {
+	var values []string
+	if len(optLines) == 1 {
+		values = strings.Split(optLines[0], ",")
+	} else {
+		values = optLines
+	}
+	opts := make([]Option, 0, len(values))
+	for _, v := range values {
+		h := extractMDHint(strings.TrimSpace(v))
+		text := strings.TrimSpace(h.text)
+		if text == "" {
+			continue
+		}
+		opt := Option{InnerXML: text}
+		if m := mdOptionCorrectRegex.FindStringSubmatch(text); m != nil {
+			opt.Correct = true
+			opt.InnerXML = strings.TrimSpace(m[1])
+		}
+		if h.hasHint {
+			opt.OptionHint = append(opt.OptionHint, OptionHint{Label: h.label, InnerXML: h.hint})
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+func isMDRangeAnswer(answer string) bool {
+	return len(answer) > 1 && strings.ContainsAny(answer[:1], "[(") && strings.ContainsAny(answer[len(answer)-1:], "])")
+}
+
+func isMDNumericalAnswer(answer string) bool {
+	return mdNumberPrefixRegex.MatchString(answer) || isMDRangeAnswer(answer)
+}
+
+// newMDNumericalResponse handles `= 5`, `= 5 +- 0.1` (tolerance) and `= [4, 6)` (range) answers
+func newMDNumericalResponse(answer string, h mdHint) *NumericalResponse {
+	nr := &NumericalResponse{Answer: answer}
+	if !isMDRangeAnswer(answer) {
+		if m := mdToleranceRegex.FindStringSubmatch(answer); m != nil {
+			nr.Answer = m[1]
+			nr.ResponseParam = &ResponseParam{Type: "tolerance", Default: strings.TrimSpace(m[2])}
+		}
+		nr.Answer = mdWhitespaceRegex.ReplaceAllString(nr.Answer, "")
+	}
+	if h.hasHint {
+		nr.CorrectHint = &CorrectHint{Label: h.label, InnerXML: h.hint}
+	}
+	return nr
+}
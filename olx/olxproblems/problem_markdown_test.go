@@ -0,0 +1,213 @@
+package olxproblems
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestNewProblemFromMD(t *testing.T) {
+	problemName := xml.Name{Local: "problem"}
+	tests := []struct {
+		name string
+		md   string
+		want *Problem
+		err  error
+	}{
+		{
+			name: "multiple choice with label and hints",
+			md: "The question text\n\n" +
+				">>Which one is `<<` in Go?||Pick the best answer<<\n\n" +
+				"( ) The left shift {{ No, that is not it }}\n" +
+				"(x) The left shift operator {{ Right:: It shifts the bits }}\n" +
+				"( ) Nothing\n",
+			want: &Problem{
+				XMLName: problemName,
+				MultipleChoiceResponse: &MultipleChoiceResponse{
+					Label: ProblemLabel{InnerXML: "Which one is `<<` in Go?"},
+					ChoiceGroup: &ChoiceGroup{Type: "MultipleChoice", Choices: []Choice{
+						{InnerXML: "The left shift", ChoiceHint: []ChoiceHint{{InnerXML: "No, that is not it"}}},
+						{Correct: true, InnerXML: "The left shift operator", ChoiceHint: []ChoiceHint{{Label: "Right", InnerXML: "It shifts the bits"}}},
+						{InnerXML: "Nothing"},
+					}},
+				},
+			},
+		},
+		{
+			name: "multi-line choice with a code block",
+			md: ">>What does it print?<<\n\n" +
+				"+(x) This:\n" +
+				"```\n" +
+				"(x) not a choice\n" +
+				"```\n" +
+				"-(x)-\n" +
+				"( ) Nothing\n",
+			want: &Problem{
+				XMLName: problemName,
+				MultipleChoiceResponse: &MultipleChoiceResponse{
+					Label: ProblemLabel{InnerXML: "What does it print?"},
+					ChoiceGroup: &ChoiceGroup{Type: "MultipleChoice", Choices: []Choice{
+						{Correct: true, InnerXML: "This:\n```\n(x) not a choice\n```"},
+						{InnerXML: "Nothing"},
+					}},
+				},
+			},
+		},
+		{
+			name: "checkboxes with selected and unselected hints",
+			md: ">>Which are types?<<\n\n" +
+				"[x] int {{ {selected: Yes, int is a type}, {unselected: int is a type too} }}\n" +
+				"[ ] func {{ {s: It is a keyword} }}\n" +
+				"[x] string {{ A plain hint stays in the text }}\n",
+			want: &Problem{
+				XMLName: problemName,
+				ChoiceResponse: &ChoiceResponse{
+					Label: ProblemLabel{InnerXML: "Which are types?"},
+					CheckboxGroup: &CheckboxGroup{Choices: []Choice{
+						{Correct: true, InnerXML: "int", ChoiceHint: []ChoiceHint{
+							{Selected: boolPtr(true), InnerXML: "Yes, int is a type"},
+							{Selected: boolPtr(false), InnerXML: "int is a type too"},
+						}},
+						{InnerXML: "func", ChoiceHint: []ChoiceHint{{Selected: boolPtr(true), InnerXML: "It is a keyword"}}},
+						{Correct: true, InnerXML: "string {{ A plain hint stays in the text }}"},
+					}},
+				},
+			},
+		},
+		{
+			name: "demand hints",
+			md: ">>How many bits in a byte?<<\n\n" +
+				"= 8\n\n" +
+				"|| Think of an octet ||\n" +
+				"|| It is 2^3 ||\n",
+			want: &Problem{
+				XMLName: problemName,
+				NumericalResponse: &NumericalResponse{
+					Label:  ProblemLabel{InnerXML: "How many bits in a byte?"},
+					Answer: "8",
+				},
+				DemandHint: &DemandHint{Hints: []string{"Think of an octet", "It is 2^3"}},
+			},
+		},
+		{
+			name: "single-line dropdown",
+			md: ">>Which keyword declares a constant?<<\n\n" +
+				"[[ var, (const) {{ Right:: const it is }}, let ]]\n",
+			want: &Problem{
+				XMLName: problemName,
+				OptionResponse: &OptionResponse{
+					Label: ProblemLabel{InnerXML: "Which keyword declares a constant?"},
+					OptionInput: &OptionInput{Options: []Option{
+						{InnerXML: "var"},
+						{Correct: true, InnerXML: "const", OptionHint: []OptionHint{{Label: "Right", InnerXML: "const it is"}}},
+						{InnerXML: "let"},
+					}},
+				},
+			},
+		},
+		{
+			name: "multi-line dropdown",
+			md: ">>Pick the zero value of a pointer<<\n\n" +
+				"[[\n" +
+				"0\n" +
+				"(nil) {{ Yes }}\n" +
+				"\"\"\n" +
+				"]]\n",
+			want: &Problem{
+				XMLName: problemName,
+				OptionResponse: &OptionResponse{
+					Label: ProblemLabel{InnerXML: "Pick the zero value of a pointer"},
+					OptionInput: &OptionInput{Options: []Option{
+						{InnerXML: "0"},
+						{Correct: true, InnerXML: "nil", OptionHint: []OptionHint{{InnerXML: "Yes"}}},
+						{InnerXML: "\"\""},
+					}},
+				},
+			},
+		},
+		{
+			name: "numerical answer with tolerance and additional answers",
+			md: ">>What is pi?<<\n\n" +
+				"= 3.14 +- 0.01 {{ Close enough }}\n" +
+				"or= 22/7\n" +
+				"or= 3.1416 {{ Precise:: Even better }}\n" +
+				"or= [3, 4]\n",
+			want: &Problem{
+				XMLName: problemName,
+				NumericalResponse: &NumericalResponse{
+					Label:         ProblemLabel{InnerXML: "What is pi?"},
+					Answer:        "3.14",
+					ResponseParam: &ResponseParam{Type: "tolerance", Default: "0.01"},
+					CorrectHint:   &CorrectHint{InnerXML: "Close enough"},
+					AdditionalAnswers: []AdditionalAnswer{
+						{Answer: "22/7"},
+						{Answer: "3.1416", CorrectHint: &CorrectHint{Label: "Precise", InnerXML: "Even better"}},
+					},
+				},
+			},
+		},
+		{
+			name: "numerical range answer",
+			md:   ">>Pick a number from 1 up to 10<<\n\n= [1, 10)\n",
+			want: &Problem{
+				XMLName: problemName,
+				NumericalResponse: &NumericalResponse{
+					Label:  ProblemLabel{InnerXML: "Pick a number from 1 up to 10"},
+					Answer: "[1, 10)",
+				},
+			},
+		},
+		{
+			name: "string answer, the first of the sections is kept and the additional answers are left out",
+			md: ">>Name the tool that formats Go code<<\n\n= gofmt\nor= go fmt\n\n---\n\n" +
+				">>And its number?<<\n\ns= 42\n",
+			want: &Problem{
+				XMLName: problemName,
+				StringResponse: &StringResponse{
+					Label:  ProblemLabel{InnerXML: "Name the tool that formats Go code"},
+					Answer: "gofmt",
+					Type:   "ci",
+				},
+			},
+		},
+		{
+			name: "regexp answer",
+			md:   ">>Name a Go keyword<<\n\n= | (var|const)\n",
+			want: &Problem{
+				XMLName: problemName,
+				StringResponse: &StringResponse{
+					Label:  ProblemLabel{InnerXML: "Name a Go keyword"},
+					Answer: "(var|const)",
+					Type:   "ci regexp",
+				},
+			},
+		},
+		{
+			name: "no response",
+			md:   "# Just a heading\n\nSome text without any choices or answers\n",
+			err:  ErrNoProblemResponse,
+		},
+		{
+			name: "response syntax only inside of a code block",
+			md:   ">>What is this?<<\n\n```\n(x) a\n[x] b\n= 5\n[[ c, (d) ]]\n```\n",
+			err:  ErrNoProblemResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewProblemFromMD(tt.md)
+			if err != tt.err {
+				t.Fatalf("got the error %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotXML, _ := xml.MarshalIndent(got, "", "  ")
+				wantXML, _ := xml.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotXML, wantXML)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package olxproblems
+
+type ResponseParam struct {
+	Type    string `xml:"type,attr"`
+	Default string `xml:"default,attr"`
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -28,6 +29,7 @@ func appendIRSequentialsToChapter(chap *Chapter, seqs []ir.Sequential) (err erro
 			Graded:      s.GetIsGraded(),
 			Format:      s.GetAssignmentType(),
 			ExtraAttrs:  mapToXMLAttrs(s.GetExtraAttributes()),
+			IsDraft:     s.GetIsDraft(),
 		}
 		err = appendIRVerticalsToSequential(newS, s.GetVerticals())
 		if err != nil {
@@ -46,7 +48,9 @@ type Sequential struct {
 	Format      string      `xml:"format,attr"`
 	ExtraAttrs  []xml.Attr  `xml:",any,attr"`
 	Verticals   []*Vertical `xml:"vertical"`
-	UpdatedAt   time.Time
+	UpdatedAt   time.Time   `xml:"-"`
+	CreatedAt   time.Time   `xml:"-"`
+	IsDraft     bool        `xml:"-"`
 }
 
 func (seq *Sequential) resolveRecursive(rootDir string) (err error) {
@@ -64,7 +68,9 @@ func (seq *Sequential) resolveRecursive(rootDir string) (err error) {
 		seq.Graded = fullSeq.Graded
 		seq.Format = fullSeq.Format
 		seq.Verticals = fullSeq.Verticals
+		seq.ExtraAttrs = fullSeq.ExtraAttrs
 	}
+	seq.ExtraAttrs, seq.IsDraft, _ = takeDraftAttrs(seq.ExtraAttrs)
 	if seq.DisplayName == "" {
 		return errors.New(fmt.Sprintf("invalid sequential: %s", seq.URLName))
 	}
@@ -77,6 +83,31 @@ func (seq *Sequential) resolveRecursive(rootDir string) (err error) {
 	return nil
 }
 
+func (seq *Sequential) exportRecursive(rootDir string) (err error) {
+	seqNode := &BlockNode{
+		XMLName: xml.Name{Local: "sequential"},
+		Attrs: []xml.Attr{
+			newXMLAttr("display_name", seq.DisplayName),
+			newXMLAttr("graded", strconv.FormatBool(seq.Graded)),
+		},
+	}
+	if seq.Format != "" {
+		seqNode.Attrs = append(seqNode.Attrs, newXMLAttr("format", seq.Format))
+	}
+	seqNode.Attrs = append(append(seqNode.Attrs, staffOnlyAttrs(seq.IsDraft)...), seq.ExtraAttrs...)
+	for _, vert := range seq.Verticals {
+		// The draft verticals point at the sequential from the drafts folder instead
+		if !vert.IsDraft {
+			seqNode.Nodes = append(seqNode.Nodes, newURLNamePointer("vertical", vert.URLName))
+		}
+		err = vert.exportRecursive(rootDir)
+		if err != nil {
+			return err
+		}
+	}
+	return writeXMLFile(rootDir, sequentialsDirName, seq.URLName, seqNode)
+}
+
 func (seq *Sequential) GetDisplayName() string {
 	return seq.DisplayName
 }
@@ -93,6 +124,10 @@ func (seq *Sequential) GetAssignmentType() string {
 	return seq.Format
 }
 
+func (seq *Sequential) GetIsDraft() bool {
+	return seq.IsDraft
+}
+
 func (seq *Sequential) GetExtraAttributes() map[string]string {
 	return xmlAttrsToMap(seq.ExtraAttrs)
 }
@@ -103,4 +138,8 @@ func (seq *Sequential) GetVerticals() []ir.Vertical {
 
 func (seq *Sequential) SetUpdatedAt(updatedAt time.Time) {
 	seq.UpdatedAt = updatedAt
-}
\ No newline at end of file
+}
+
+func (seq *Sequential) SetCreatedAt(createdAt time.Time) {
+	seq.CreatedAt = createdAt
+}
@@ -3,6 +3,10 @@ package olx
 import (
 	"encoding/xml"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 )
 
 func urlNameToXMLFileName(urlName string) string {
@@ -22,9 +26,44 @@ func xmlAttrsToMap(attrs []xml.Attr) map[string]string {
 }
 
 func mapToXMLAttrs(m map[string]string) []xml.Attr {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	// Sort the keys so that the exported files are stable between runs
+	sort.Strings(keys)
 	ret := make([]xml.Attr, 0, len(m))
-	for k, v := range m {
-		ret = append(ret, xml.Attr{Name: xml.Name{Local: k}, Value: v})
+	for _, k := range keys {
+		ret = append(ret, xml.Attr{Name: xml.Name{Local: k}, Value: m[k]})
 	}
 	return ret
 }
+
+func newXMLAttr(name, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
+
+// newURLNamePointer creates the `<type url_name="..."/>` node that the split OLX layout uses to reference a child defined in its own file
+func newURLNamePointer(nodeType, urlName string) *BlockNode {
+	return &BlockNode{
+		XMLName: xml.Name{Local: nodeType},
+		Attrs:   []xml.Attr{newXMLAttr("url_name", urlName)},
+	}
+}
+
+// writeXMLFile marshals the object into `<rootDir>/<dirName>/<urlName>.xml`, creating the directory if needed
+func writeXMLFile(rootDir, dirName, urlName string, object interface{}) (err error) {
+	outXML, err := xml.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return writeFile(rootDir, dirName, urlNameToXMLFileName(urlName), append(outXML, '\n'))
+}
+
+func writeFile(rootDir, dirName, fileName string, contents []byte) (err error) {
+	err = os.MkdirAll(filepath.Join(rootDir, dirName), 0775)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(rootDir, dirName, fileName), contents, 0755)
+}
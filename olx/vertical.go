@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -26,6 +27,11 @@ func appendIRVerticalsToSequential(seq *Sequential, verts []ir.Vertical) (err er
 			URLName:     v.GetURLName(),
 			DisplayName: v.GetDisplayName(),
 			ExtraAttrs:  mapToXMLAttrs(v.GetExtraAttributes()),
+			IsDraft:     v.GetIsDraft(),
+		}
+		newV.Assets, err = irAssetsToAssets(v.GetAssets())
+		if err != nil {
+			return err
 		}
 		err = appendIRBlocksToVertical(newV, v.GetBlocks())
 		if err != nil {
@@ -42,7 +48,13 @@ type Vertical struct {
 	DisplayName string     `xml:"display_name,attr"`
 	ExtraAttrs  []xml.Attr `xml:",any,attr"`
 	Blocks      []*Block   `xml:",any"`
-	UpdatedAt   time.Time
+	UpdatedAt   time.Time  `xml:"-"`
+	CreatedAt   time.Time  `xml:"-"`
+	Assets      []*Asset   `xml:"-"`
+	IsDraft     bool       `xml:"-"`
+	// The sequential and the position in it of a draft vertical, which is exported into the drafts folder
+	draftParentURL string
+	draftIndex     int
 }
 
 func (vert *Vertical) resolveRecursive(rootDir string) (err error) {
@@ -58,7 +70,9 @@ func (vert *Vertical) resolveRecursive(rootDir string) (err error) {
 		}
 		vert.DisplayName = fullVert.DisplayName
 		vert.Blocks = fullVert.Blocks
+		vert.ExtraAttrs = fullVert.ExtraAttrs
 	}
+	vert.ExtraAttrs, vert.IsDraft, _ = takeDraftAttrs(vert.ExtraAttrs)
 	if vert.DisplayName == "" {
 		return errors.New(fmt.Sprintf("invalid vertical: %s", vert.URLName))
 	}
@@ -71,6 +85,31 @@ func (vert *Vertical) resolveRecursive(rootDir string) (err error) {
 	return nil
 }
 
+func (vert *Vertical) exportRecursive(rootDir string) (err error) {
+	vertNode := &BlockNode{
+		XMLName: xml.Name{Local: "vertical"},
+		Attrs:   append([]xml.Attr{newXMLAttr("display_name", vert.DisplayName)}, vert.ExtraAttrs...),
+	}
+	vertDir := rootDir
+	if vert.IsDraft {
+		// Studio keeps the unpublished verticals and their blocks in the drafts folder
+		vertDir = filepath.Join(rootDir, draftsDirName)
+		vertNode.Attrs = append(vertNode.Attrs, newXMLAttr(draftParentURLAttr, vert.draftParentURL), newXMLAttr(draftIndexAttr, strconv.Itoa(vert.draftIndex)))
+	}
+	for _, blk := range vert.Blocks {
+		vertNode.Nodes = append(vertNode.Nodes, newURLNamePointer(blk.XMLName.Local, blk.URLName))
+		err = blk.exportRecursive(vertDir)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeStaticAssets(rootDir, vert.URLName, vert.Assets)
+	if err != nil {
+		return err
+	}
+	return writeXMLFile(vertDir, verticalsDirName, vert.URLName, vertNode)
+}
+
 func (vert *Vertical) GetDisplayName() string {
 	return vert.DisplayName
 }
@@ -83,10 +122,22 @@ func (vert *Vertical) GetExtraAttributes() map[string]string {
 	return xmlAttrsToMap(vert.ExtraAttrs)
 }
 
+func (vert *Vertical) GetIsDraft() bool {
+	return vert.IsDraft
+}
+
 func (vert *Vertical) GetBlocks() []ir.Block {
 	return blocksToIRBlocks(vert.Blocks)
 }
 
-func (vert *Vertical) SetUpdatedAt(updatedAt time.Time)  {
+func (vert *Vertical) GetAssets() []ir.Asset {
+	return assetsToIRAssets(vert.Assets)
+}
+
+func (vert *Vertical) SetUpdatedAt(updatedAt time.Time) {
 	vert.UpdatedAt = updatedAt
 }
+
+func (vert *Vertical) SetCreatedAt(createdAt time.Time) {
+	vert.CreatedAt = createdAt
+}
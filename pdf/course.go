@@ -9,8 +9,11 @@ import (
 	"strings"
 )
 
-func exportCourseRecursive(course ir.Course, rootDir string) (err error) {
-	courseMD, err := concatMarkdownFile(course)
+// draftHeadingSuffix marks the headings of the drafts, when they are included
+const draftHeadingSuffix = " (Draft)"
+
+func exportCourseRecursive(course ir.Course, rootDir string, includeDrafts bool) (err error) {
+	courseMD, err := concatMarkdownFile(course, includeDrafts)
 	if err != nil {
 		return err
 	}
@@ -25,16 +28,25 @@ func exportCourseRecursive(course ir.Course, rootDir string) (err error) {
 	return nil
 }
 
-func concatMarkdownFile(course ir.Course) (string, error) {
+func concatMarkdownFile(course ir.Course, includeDrafts bool) (string, error) {
 	mdStr := strings.Builder{}
 	for _, chap := range course.GetChapters() {
+		if chap.GetIsDraft() && !includeDrafts {
+			continue
+		}
 		// Write the chapter heading
-		mdStr.WriteString(fmt.Sprintf("\n# %s\n\n", chap.GetDisplayName()))
+		mdStr.WriteString(fmt.Sprintf("\n# %s%s\n\n", chap.GetDisplayName(), draftSuffix(chap.GetIsDraft())))
 		for _, seq := range chap.GetSequentials() {
+			if seq.GetIsDraft() && !includeDrafts {
+				continue
+			}
 			// Write the section heading
-			mdStr.WriteString(fmt.Sprintf("\n# %s\n\n", seq.GetDisplayName()))
+			mdStr.WriteString(fmt.Sprintf("\n# %s%s\n\n", seq.GetDisplayName(), draftSuffix(seq.GetIsDraft())))
 			for _, vert := range seq.GetVerticals() {
-				mdStr.WriteString(fmt.Sprintf("\n## %s\n\n", vert.GetDisplayName()))
+				if vert.GetIsDraft() && !includeDrafts {
+					continue
+				}
+				mdStr.WriteString(fmt.Sprintf("\n## %s%s\n\n", vert.GetDisplayName(), draftSuffix(vert.GetIsDraft())))
 				for _, blk := range vert.GetBlocks() {
 					if blk.GetBlockType() != "html" {
 						continue
@@ -52,3 +64,10 @@ func concatMarkdownFile(course ir.Course) (string, error) {
 	}
 	return mdStr.String(), nil
 }
+
+func draftSuffix(isDraft bool) string {
+	if isDraft {
+		return draftHeadingSuffix
+	}
+	return ""
+}
@@ -15,6 +15,8 @@ func NewPDFExtFmt() *PDF {
 }
 
 type PDF struct {
+	// IncludeDrafts makes Export include the drafts of the course, with their headings marked as such
+	IncludeDrafts bool
 }
 
 func (o *PDF) Import(fromUri string) (toIntermediateRepresentation ir.Course, err error) {
@@ -36,5 +38,5 @@ func (o *PDF) Export(fromIntermediateRepresentation ir.Course, toUri string, for
 	if err != nil {
 		return err
 	}
-	return exportCourseRecursive(fromIntermediateRepresentation, rootDir)
+	return exportCourseRecursive(fromIntermediateRepresentation, rootDir, o.IncludeDrafts)
 }